@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+)
+
+var (
+	canonFile   string
+	canonDedupe bool
+)
+
+func init() {
+	canonCmd := &cobra.Command{
+		Use:   "canon [puzzle...]",
+		Short: "Put Sudoku puzzles in canonical form",
+		Long: `Print the minlex form of one or more Sudoku puzzles: the smallest puzzle, read
+cell by cell with empty cells first, that each can be turned into by relabeling
+the digits, reordering bands, stacks and the rows and columns within them, and
+transposing. Two puzzles are the same puzzle in disguise exactly when their minlex
+forms are equal. Grids up to 12x12 are supported, without variant rules or clues.
+
+With --dedupe the puzzles are printed as given, leaving out any puzzle equivalent
+to one printed before it.
+
+Puzzles are read like in 'sudoku solve': from the arguments, a file, or stdin.
+
+Examples:
+  sudoku canon 53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79
+  sudoku canon --file puzzles.txt
+  sudoku gen -n 100 --output line | cut -d, -f1 | sudoku canon --dedupe > distinct.txt`,
+		RunE: runCanon,
+
+		// Puzzles without a canonical form are not usage mistakes
+		SilenceUsage: true,
+	}
+
+	canonCmd.Flags().StringVarP(&canonFile, "file", "f", "", "Read puzzles from a file, one per line ('-' for stdin)")
+	canonCmd.Flags().BoolVar(&canonDedupe, "dedupe", false, "Print the puzzles as given, leaving out those equivalent to an earlier one")
+
+	rootCmd.AddCommand(canonCmd)
+}
+
+func runCanon(cmd *cobra.Command, args []string) error {
+	out, err := newOutput(cmd)
+	if err != nil {
+		return err
+	}
+	puzzles, err := readPuzzles(args, canonFile, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(puzzles) == 0 {
+		return errors.New("no puzzles given")
+	}
+
+	// seen holds the minlex form of every puzzle printed so far
+	seen := make(map[string]bool)
+	return forEachPuzzle(cmd, puzzles, "canonicalized", func(puzzle string) error {
+		b, err := parsePuzzle(puzzle)
+		if err != nil {
+			return err
+		}
+		canonical, err := b.Canonicalize()
+		if err != nil {
+			return err
+		}
+
+		key := canonical.String()
+		if canonDedupe {
+			if seen[key] {
+				return nil
+			}
+			seen[key] = true
+			canonical = b
+		}
+
+		return out.write(newRecord(canonical, quickSolution(canonical)), func(w io.Writer) {
+			fmt.Fprintln(w, canonical)
+		})
+	})
+}
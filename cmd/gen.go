@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
 	"github.com/rybkr/sudoku/internal/generator"
+	"github.com/rybkr/sudoku/internal/rating"
+	"github.com/rybkr/sudoku/internal/solver"
 	"github.com/spf13/cobra"
+	"io"
+	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -11,45 +18,298 @@ var (
 	numPuzzles int
 	clueCount  int
 	timeout    time.Duration
+	seed       int64
+	symmetry   string
+	difficulty string
+	require    []string
+	forbid     []string
+	workers    int
+	size       int
+	killer     bool
+	jigsaw     bool
+	extraUnits string
+	clueKinds  string
+	rules      string
+	layoutName string
 )
 
 func init() {
 	genCmd := &cobra.Command{
 		Use:   "gen",
 		Short: "Generate Sudoku puzzles",
-		Long: `Generate one or more Sudoku puzzles with a specified difficulty level.
+		Long: `Generate one or more Sudoku puzzles with a specified clue count or difficulty level.
+
+With --killer the solution is split into cages, and --clueCount caps the givens
+added to make the solution unique, none by default. --clues adds variant clues
+read off the solution in the same way: sandwich sums for every row and column,
+and about one arrow, thermometer or little killer diagonal per row, shared between
+the kinds chosen. Little killers, and grids larger than 9x9, can take longer than
+the default --timeout.
+
+With --layout several 9x9 grids overlap in shared boxes: twodoku, butterfly or
+samurai. Their clues are dug for as long as the solution stays unique, so
+--clueCount and the other puzzle options do not apply.
+
+A difficulty is one of Easy, Medium, Hard, Expert or Diabolical, or a range such
+as medium-expert. Puzzles are dug until their rating falls in that range, with
+--clueCount as the fewest clues allowed.
 
 Examples:
   sudoku gen --clueCount 40
   sudoku gen -n 5 --clueCount 30
-  sudoku gen --clueCount 20 --timeout 15s`,
+  sudoku gen --clueCount 20 --timeout 15s
+  sudoku gen -n 3 --seed 42
+  sudoku gen --size 6
+  sudoku gen --size 16 --clueCount 120
+  sudoku gen --symmetry rotational
+  sudoku gen --units x
+  sudoku gen --units windoku,centre-dot --clueCount 24
+  sudoku gen --rules anti-knight
+  sudoku gen --rules anti-knight,anti-king --clueCount 26
+  sudoku gen --jigsaw
+  sudoku gen --jigsaw --units x
+  sudoku gen --killer
+  sudoku gen --killer --clueCount 4
+  sudoku gen --clues sandwich
+  sudoku gen --clues thermo,little-killer --timeout 30s
+  sudoku gen --layout samurai
+  sudoku gen --layout twodoku -n 5 --output line
+  sudoku gen --difficulty hard
+  sudoku gen --difficulty medium-expert --require x-wing --forbid unique-rectangle
+  sudoku gen -n 100 --output line > puzzles.csv
+  sudoku gen -n 10000 --seed 1 --workers 8 --output json > book.jsonl
+  sudoku gen --output json --difficulty hard`,
 		RunE: runGen,
 	}
 
 	genCmd.Flags().IntVarP(&numPuzzles, "number", "n", 1, "Number of puzzles to generate")
-	genCmd.Flags().IntVarP(&clueCount, "clueCount", "c", generator.DefaultClueCount, "Number of clues 17-80")
+	genCmd.Flags().IntVarP(&clueCount, "clueCount", "c", generator.DefaultClueCount, "Number of clues, 17-80 for 9x9 (default scales with --size, rounded up to suit --symmetry)")
 	genCmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "Generation timeout per puzzle")
+	genCmd.Flags().Int64Var(&seed, "seed", 0, "Seed for reproducible puzzles, puzzle i uses seed+i skipping 0 (0 = random)")
+	genCmd.Flags().StringVar(&symmetry, "symmetry", "none", "Clue pattern symmetry: none, rotational, rotational90, horizontal, vertical, diagonal or antidiagonal")
+	genCmd.Flags().StringVarP(&difficulty, "difficulty", "d", "", "Target difficulty level or range, e.g. hard or medium-expert")
+	genCmd.Flags().StringSliceVar(&require, "require", nil, "Techniques the solve path must use")
+	genCmd.Flags().StringSliceVar(&forbid, "forbid", nil, "Techniques the solve path must not use")
+	genCmd.Flags().IntVar(&size, "size", 9, "Grid size: 4, 6, 9, 12, 16 or 25")
+	genCmd.Flags().StringVar(&extraUnits, "units", "none", "Extra units every digit must appear in once: x, windoku and/or centre-dot")
+	genCmd.Flags().StringVar(&rules, "rules", "none", "Chess move rules forbidding repeated digits: anti-knight and/or anti-king")
+	genCmd.Flags().BoolVar(&jigsaw, "jigsaw", false, "Generate each puzzle on a random layout of irregular regions")
+	genCmd.Flags().BoolVar(&killer, "killer", false, "Generate killer puzzles of cages with few or no givens")
+	genCmd.Flags().StringVar(&clueKinds, "clues", "none", "Variant clues to add: sandwich, arrow, thermo and/or little-killer")
+	genCmd.Flags().StringVar(&layoutName, "layout", "", "Multi-grid layout of overlapping grids: twodoku, butterfly or samurai")
+	genCmd.Flags().IntVar(&workers, "workers", 0, "Number of puzzles to generate in parallel (0 = one per CPU)")
 
 	rootCmd.AddCommand(genCmd)
 }
 
 func runGen(cmd *cobra.Command, args []string) error {
-	for i := 0; i < numPuzzles; i++ {
-		opts := generator.DefaultOptions(clueCount)
+	out, err := newOutput(cmd)
+	if err != nil {
+		return err
+	}
+	target, err := parseTarget(difficulty, require, forbid)
+	if err != nil {
+		return err
+	}
+	sym, err := generator.ParseSymmetry(symmetry)
+	if err != nil {
+		return err
+	}
+
+	// Pick a master seed up front so that every record can be reproduced
+	master := seed
+	for master == 0 {
+		master = rand.Int63()
+	}
+
+	geo, err := board.GeometryFor(size)
+	if err != nil {
+		return err
+	}
+	extras, err := board.ParseExtraUnits(extraUnits)
+	if err != nil {
+		return err
+	}
+	if geo, err = geo.WithExtraUnits(extras); err != nil {
+		return err
+	}
+	moves, err := board.ParseRules(rules)
+	if err != nil {
+		return err
+	}
+	if geo, err = geo.WithRules(moves); err != nil {
+		return err
+	}
+	clues, err := generator.ParseClueKinds(clueKinds)
+	if err != nil {
+		return err
+	}
+	if killer && (target != nil || sym != generator.SymmetryNone) {
+		return errors.New("--killer cannot be combined with --difficulty, --require, --forbid or --symmetry")
+	}
+	if clues != 0 && (target != nil || sym != generator.SymmetryNone) {
+		return errors.New("--clues cannot be combined with --difficulty, --require, --forbid or --symmetry")
+	}
+	if clues != 0 && !killer && clueCount < 1 {
+		return errors.New("--clues needs a --clueCount of at least 1, or --killer to start from no givens")
+	}
+
+	opts := generator.DefaultOptions(generator.DefaultClueCount)
+	opts.Seed = master
+	if layoutName != "" {
+		layout, err := board.LayoutFor(layoutName)
+		if err != nil {
+			return err
+		}
+		if geo != board.Standard || jigsaw || killer || clues != 0 || target != nil || sym != generator.SymmetryNone || cmd.Flags().Changed("clueCount") {
+			return errors.New("--layout cannot be combined with --size, --units, --rules, --jigsaw, --killer, --clues, --clueCount, --difficulty, --require, --forbid or --symmetry")
+		}
 		opts.Timeout = timeout
-		gen := generator.New(opts)
+		return genMulti(cmd, out, layout, opts)
+	}
+	opts.ClueCount = clueCountFor(cmd, clueCount, geo, target, sym)
+	if killer && !cmd.Flags().Changed("clueCount") {
+		opts.ClueCount = 0
+	}
+	opts.Timeout = timeout
+	opts.Target = target
+	opts.Symmetry = sym
+	opts.Geometry = geo
+	opts.Killer = killer
+	opts.Jigsaw = jigsaw
+	opts.Clues = clues
+	opts.Rate = out.format == outputJSON
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	// A failed puzzle is reported and the batch goes on, like failed puzzles in solve
+	cmd.SilenceUsage = true
+	failed := 0
+	for res := range generator.GenerateBatch(cmd.Context(), numPuzzles, opts, workers) {
+		if res.Err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "puzzle %d (seed %d): generation failed: %v\n", res.Index+1, res.Seed, res.Err)
+			failed++
+			continue
+		}
+		puzzle, solution, r := res.Puzzle, res.Solution, res.Rating
 
-		puzzle, solution, err := gen.Generate()
+		rec := newRecord(puzzle, solution)
+		rec.Seed = res.Seed
+		rec.GenerationTime = float64(res.Elapsed) / float64(time.Millisecond)
+		if r != nil {
+			rec.Rating = newRatingRecord(r, false)
+		}
+
+		err = out.write(rec, func(w io.Writer) {
+			fmt.Fprintln(w, "Puzzle:")
+			fmt.Fprintln(w, puzzle.Format())
+			if target != nil && r != nil {
+				fmt.Fprintln(w, "Difficulty:", r)
+			}
+			fmt.Fprintln(w, "\nSolution:")
+			fmt.Fprintln(w, solution.Format())
+			fmt.Fprintln(w)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.Context().Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d puzzles could not be generated", failed, numPuzzles)
+	}
+	return nil
+}
+
+// genMulti generates multi-grid puzzles one after another, puzzle i from the seed
+// it would have in a batch.
+func genMulti(cmd *cobra.Command, out *output, layout *board.Layout, opts *generator.Options) error {
+	master := opts.Seed
+	for i := 0; i < numPuzzles; i++ {
+		opts.Seed = generator.BatchSeed(master, i)
+		start := time.Now()
+		puzzle, solution, err := generator.New(opts).GenerateMultiContext(cmd.Context(), layout)
 		if err != nil {
 			return fmt.Errorf("generation failed: %w", err)
 		}
 
-		fmt.Println("Puzzle:")
-		fmt.Println(puzzle.Format())
-		fmt.Println("\nSolution:")
-		fmt.Println(solution.Format())
-		fmt.Println()
+		rec := newMultiRecord(puzzle, solution)
+		rec.Seed = opts.Seed
+		rec.GenerationTime = float64(time.Since(start)) / float64(time.Millisecond)
+
+		err = out.write(rec, func(w io.Writer) {
+			fmt.Fprintln(w, "Puzzle:")
+			fmt.Fprintln(w, puzzle.Format())
+			fmt.Fprintln(w, "\nSolution:")
+			fmt.Fprintln(w, solution.Format())
+			fmt.Fprintln(w)
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// clueCountFor picks the clue count of generated puzzles: the --clueCount flag if given,
+// otherwise as few as possible when digging to a difficulty, or the default for the grid size
+// rounded up to a count the symmetry can leave.
+func clueCountFor(cmd *cobra.Command, flagValue int, geo *board.Geometry, target *generator.Target, sym generator.Symmetry) int {
+	switch {
+	case cmd.Flags().Changed("clueCount"):
+		return flagValue
+	case target != nil:
+		lo, _ := generator.ClueCountRange(geo)
+		return lo
+	default:
+		return sym.ClueCountAtLeast(geo, generator.DefaultClueCountFor(geo))
+	}
+}
+
+// parseTarget builds a generation target from the difficulty flags.
+// Returns nil if none of them are set.
+func parseTarget(levels string, required, forbidden []string) (*generator.Target, error) {
+	if levels == "" && len(required) == 0 && len(forbidden) == 0 {
+		return nil, nil
+	}
+
+	target := &generator.Target{MinLevel: rating.Easy, MaxLevel: rating.Diabolical}
+	if levels != "" {
+		lo, hi, isRange := strings.Cut(levels, "-")
+		if !isRange {
+			hi = lo
+		}
+		var err error
+		if target.MinLevel, err = rating.ParseLevel(lo); err != nil {
+			return nil, err
+		}
+		if target.MaxLevel, err = rating.ParseLevel(hi); err != nil {
+			return nil, err
+		}
+		if target.MinLevel > target.MaxLevel {
+			return nil, fmt.Errorf("difficulty range %q is empty", levels)
+		}
+	}
+
+	for _, name := range required {
+		t, err := solver.ParseTechnique(name)
+		if err != nil {
+			return nil, err
+		}
+		target.Required = append(target.Required, t)
+	}
+	for _, name := range forbidden {
+		t, err := solver.ParseTechnique(name)
+		if err != nil {
+			return nil, err
+		}
+		target.Forbidden = append(target.Forbidden, t)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rybkr/sudoku/internal/hint"
+	"github.com/spf13/cobra"
+	"io"
+)
+
+var (
+	hintFile  string
+	hintLevel string
+	hintAll   bool
+)
+
+func init() {
+	hintCmd := &cobra.Command{
+		Use:   "hint [puzzle...]",
+		Short: "Show the next logical step for Sudoku puzzles",
+		Long: `Show the easiest next logical step for one or more partially filled puzzles.
+
+Hints can be revealed progressively with --level:
+  area       which cells or units to look at
+  technique  which technique applies there
+  digit      which digits the technique works on
+  answer     the complete step and its result
+
+Puzzles are read like in 'sudoku solve': from the arguments, a file, or stdin.
+
+Examples:
+  sudoku hint 4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......
+  sudoku hint --level area < puzzle.txt
+  sudoku hint --all --file puzzles.txt`,
+		RunE: runHint,
+
+		// Puzzles without a hint are not usage mistakes
+		SilenceUsage: true,
+	}
+
+	hintCmd.Flags().StringVarP(&hintFile, "file", "f", "", "Read puzzles from a file, one per line ('-' for stdin)")
+	hintCmd.Flags().StringVarP(&hintLevel, "level", "l", "answer", "How much to reveal: area, technique, digit or answer")
+	hintCmd.Flags().BoolVar(&hintAll, "all", false, "Print every level, from vaguest to complete")
+
+	rootCmd.AddCommand(hintCmd)
+}
+
+func runHint(cmd *cobra.Command, args []string) error {
+	out, err := newOutput(cmd)
+	if err != nil {
+		return err
+	}
+	level, err := hint.ParseLevel(hintLevel)
+	if err != nil {
+		return err
+	}
+	puzzles, err := readPuzzles(args, hintFile, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(puzzles) == 0 {
+		return errors.New("no puzzles given")
+	}
+
+	return forEachPuzzle(cmd, puzzles, "hinted", func(puzzle string) error {
+		b, err := parsePuzzle(puzzle)
+		if err != nil {
+			return err
+		}
+
+		h, err := hint.Next(b)
+		if err != nil {
+			return err
+		}
+
+		rec := newRecord(b, quickSolution(b))
+		if hintAll {
+			rec.Hints = h.Progression()
+		} else {
+			rec.Hints = []string{h.Reveal(level)}
+		}
+		return out.write(rec, func(w io.Writer) {
+			for _, line := range rec.Hints {
+				fmt.Fprintln(w, line)
+			}
+		})
+	})
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/rating"
+	"github.com/rybkr/sudoku/internal/solver"
+	"github.com/spf13/cobra"
+	"io"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by --output
+const (
+	outputText = "text" // Human-readable report, different for every subcommand
+	outputJSON = "json" // One JSON record per line
+	outputLine = "line" // One "puzzle,solution" pair per line
+)
+
+var outputFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text, json or line")
+}
+
+// record is a single result printed by a subcommand.
+// Fields a subcommand knows nothing about are left out of the JSON.
+type record struct {
+	Puzzle         string        `json:"puzzle"`
+	Solution       string        `json:"solution,omitempty"`
+	Clues          int           `json:"clues"`
+	Seed           int64         `json:"seed,omitempty"`
+	GenerationTime float64       `json:"generation_ms,omitempty"`
+	Rating         *ratingRecord `json:"rating,omitempty"`
+	Hints          []string      `json:"hints,omitempty"`
+}
+
+// ratingRecord is the JSON form of a rating.Rating.
+type ratingRecord struct {
+	Level      string         `json:"level"`
+	Score      float64        `json:"score"`
+	Effort     float64        `json:"effort"`
+	Hardest    string         `json:"hardest,omitempty"`
+	Solved     bool           `json:"solved"`
+	Techniques map[string]int `json:"techniques,omitempty"`
+	Steps      []string       `json:"steps,omitempty"`
+}
+
+// newRecord describes a puzzle and, if known, its solution.
+func newRecord(puzzle, solution *board.Board) *record {
+	r := &record{
+		Puzzle: puzzle.String(),
+		Clues:  puzzle.ClueCount(),
+	}
+	if solution != nil {
+		r.Solution = solution.GridString()
+	}
+	return r
+}
+
+// newMultiRecord describes a multi-grid puzzle and, if known, its solution.
+func newMultiRecord(puzzle, solution *board.Multi) *record {
+	r := &record{
+		Puzzle: puzzle.String(),
+		Clues:  puzzle.ClueCount(),
+	}
+	if solution != nil {
+		r.Solution = solution.GridString()
+	}
+	return r
+}
+
+// newRatingRecord converts a rating, including its steps if asked to.
+func newRatingRecord(r *rating.Rating, steps bool) *ratingRecord {
+	rr := &ratingRecord{
+		Level:      r.Level.String(),
+		Score:      r.Score,
+		Effort:     r.Effort,
+		Solved:     r.Solved,
+		Techniques: make(map[string]int, len(r.Counts)),
+	}
+	if len(r.Steps) > 0 {
+		rr.Hardest = r.Hardest.String()
+	}
+	for t, n := range r.Counts {
+		rr.Techniques[t.String()] = n
+	}
+	if steps {
+		for _, step := range r.Steps {
+			rr.Steps = append(rr.Steps, step.String())
+		}
+	}
+	return rr
+}
+
+// output writes records in the format selected with --output.
+type output struct {
+	format string
+	w      io.Writer
+}
+
+// newOutput returns the output of a command, checking the --output flag.
+func newOutput(cmd *cobra.Command) (*output, error) {
+	switch outputFormat {
+	case outputText, outputJSON, outputLine:
+		return &output{format: outputFormat, w: cmd.OutOrStdout()}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, want text, json or line", outputFormat)
+	}
+}
+
+// write prints a record. In text mode the record is left to text,
+// which prints the subcommand's human-readable report instead.
+func (o *output) write(r *record, text func(w io.Writer)) error {
+	switch o.format {
+	case outputJSON:
+		return json.NewEncoder(o.w).Encode(r)
+	case outputLine:
+		_, err := fmt.Fprintf(o.w, "%s,%s\n", r.Puzzle, r.Solution)
+		return err
+	default:
+		text(o.w)
+		return nil
+	}
+}
+
+// parsePuzzle parses a puzzle string, reporting malformed puzzles as solver.ErrInvalidPuzzle.
+func parsePuzzle(puzzle string) (*board.Board, error) {
+	b, err := board.NewFromString(puzzle)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", solver.ErrInvalidPuzzle, err)
+	}
+	return b, nil
+}
+
+// parseMultiPuzzle parses a multi-grid puzzle string, one that starts with the name
+// of a layout and a colon. Reports false if the string names no layout.
+func parseMultiPuzzle(puzzle string) (*board.Multi, bool, error) {
+	name, _, ok := strings.Cut(puzzle, ":")
+	if !ok {
+		return nil, false, nil
+	}
+	if _, err := board.LayoutFor(strings.TrimSpace(name)); err != nil {
+		return nil, false, nil
+	}
+	m, err := board.NewMultiFromString(puzzle)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %v", solver.ErrInvalidPuzzle, err)
+	}
+	return m, true, nil
+}
+
+// quickSolution solves a puzzle for the record of a subcommand that does not
+// otherwise need its solution. Returns nil if none is found within a second.
+func quickSolution(b *board.Board) *board.Board {
+	opts := solver.DefaultOptions()
+	opts.Algorithm = solver.DancingLinks
+	opts.Timeout = time.Second
+	solution, err := solver.New(b, opts).Solve()
+	if err != nil {
+		return nil
+	}
+	return solution
+}
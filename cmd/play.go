@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/generator"
+	"github.com/rybkr/sudoku/internal/play"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+var (
+	playClueCount  int
+	playSeed       int64
+	playDifficulty string
+	playSize       int
+)
+
+func init() {
+	playCmd := &cobra.Command{
+		Use:   "play [puzzle]",
+		Short: "Play a Sudoku puzzle in the terminal",
+		Long: `Play a Sudoku puzzle full-screen in the terminal.
+
+The puzzle is given as a string of one character per cell, 16, 36 or 81 characters
+for 4x4, 6x6 or 9x9 grids, or generated when omitted.
+
+Keys:
+  arrows or hjkl   move the cursor
+  1-9              enter a digit, or toggle a pencil mark in pencil mode
+  0, x, backspace  erase the digit, or the pencil marks of an empty cell
+  p                switch between digit and pencil mode
+  u / r            undo / redo
+  ?                hint; press again to reveal more
+  c                check for mistakes
+  q                quit
+
+Examples:
+  sudoku play
+  sudoku play --difficulty hard
+  sudoku play --seed 42
+  sudoku play --size 6
+  sudoku play 4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runPlay,
+	}
+
+	playCmd.Flags().IntVarP(&playClueCount, "clueCount", "c", generator.DefaultClueCount, "Number of clues in a generated puzzle, 17-80 for 9x9")
+	playCmd.Flags().Int64Var(&playSeed, "seed", 0, "Seed for a reproducible generated puzzle (0 = random)")
+	playCmd.Flags().IntVar(&playSize, "size", 9, "Size of a generated grid: 4, 6 or 9")
+	playCmd.Flags().StringVarP(&playDifficulty, "difficulty", "d", "", "Difficulty level or range of a generated puzzle, e.g. easy or medium-hard")
+
+	rootCmd.AddCommand(playCmd)
+}
+
+func runPlay(cmd *cobra.Command, args []string) error {
+	// The game draws its own screen, so there are no records to format
+	if out, err := newOutput(cmd); err != nil {
+		return err
+	} else if out.format != outputText {
+		return fmt.Errorf("play is interactive and does not support --output %s", out.format)
+	}
+
+	var puzzle, solution *board.Board
+	if len(args) == 1 {
+		var err error
+		if puzzle, err = board.NewFromString(args[0]); err != nil {
+			return fmt.Errorf("invalid puzzle: %w", err)
+		}
+	} else {
+		geo, err := board.GeometryFor(playSize)
+		if err != nil {
+			return err
+		}
+		target, err := parseTarget(playDifficulty, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		opts := generator.DefaultOptions(generator.DefaultClueCount)
+		opts.ClueCount = clueCountFor(cmd, playClueCount, geo, target, generator.SymmetryNone)
+		opts.Timeout = 10 * time.Second
+		opts.Seed = playSeed
+		opts.Target = target
+		opts.Geometry = geo
+
+		if puzzle, solution, err = generator.New(opts).Generate(); err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
+	}
+
+	game, err := play.New(puzzle, solution)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+	return play.Run(game, os.Stdin)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rybkr/sudoku/internal/rating"
+	"github.com/rybkr/sudoku/internal/solver"
+	"github.com/spf13/cobra"
+	"io"
+	"strings"
+)
+
+var (
+	rateFile  string
+	rateSteps bool
+)
+
+func init() {
+	rateCmd := &cobra.Command{
+		Use:   "rate [puzzle...]",
+		Short: "Rate the difficulty of Sudoku puzzles",
+		Long: `Rate one or more Sudoku puzzles by solving them with human-style techniques.
+The score is the weight of the hardest technique needed, which maps to one of
+Easy, Medium, Hard, Expert or Diabolical. Puzzles that cannot be finished
+without guessing are rated Diabolical.
+
+Puzzles are read like in 'sudoku solve': from the arguments, a file, or stdin.
+
+Examples:
+  sudoku rate 4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......
+  sudoku rate --file puzzles.txt
+  sudoku rate --steps < puzzle.txt`,
+		RunE: runRate,
+
+		// Unratable puzzles are not usage mistakes
+		SilenceUsage: true,
+	}
+
+	rateCmd.Flags().StringVarP(&rateFile, "file", "f", "", "Read puzzles from a file, one per line ('-' for stdin)")
+	rateCmd.Flags().BoolVar(&rateSteps, "steps", false, "Print every solving step")
+
+	rootCmd.AddCommand(rateCmd)
+}
+
+func runRate(cmd *cobra.Command, args []string) error {
+	out, err := newOutput(cmd)
+	if err != nil {
+		return err
+	}
+	puzzles, err := readPuzzles(args, rateFile, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(puzzles) == 0 {
+		return errors.New("no puzzles given")
+	}
+
+	return forEachPuzzle(cmd, puzzles, "rated", func(puzzle string) error {
+		b, err := parsePuzzle(puzzle)
+		if err != nil {
+			return err
+		}
+		r, err := rating.Rate(b)
+		if err != nil {
+			return err
+		}
+
+		rec := newRecord(b, quickSolution(b))
+		rec.Rating = newRatingRecord(r, rateSteps)
+		return out.write(rec, func(w io.Writer) {
+			fmt.Fprintln(w, puzzle)
+			fmt.Fprintln(w, formatRating(r))
+		})
+	})
+}
+
+// formatRating renders a rating as a short human-readable report.
+func formatRating(r *rating.Rating) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Difficulty: %s\n", r)
+
+	hardest, counts := "none", []string{}
+	if len(r.Steps) > 0 {
+		hardest = r.Hardest.String()
+	}
+	if !r.Solved {
+		hardest += ", then guessing"
+	}
+	for _, t := range solver.Techniques() {
+		if n := r.Counts[t]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%s x%d", t, n))
+		}
+	}
+	if len(counts) == 0 {
+		counts = append(counts, "none")
+	}
+	fmt.Fprintf(&sb, "Hardest:    %s\n", hardest)
+	fmt.Fprintf(&sb, "Techniques: %s\n", strings.Join(counts, ", "))
+
+	if rateSteps {
+		for i, step := range r.Steps {
+			fmt.Fprintf(&sb, "%3d. %s\n", i+1, step.String())
+		}
+	}
+
+	return sb.String()
+}
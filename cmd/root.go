@@ -1,20 +1,67 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"github.com/rybkr/sudoku/internal/solver"
 	"github.com/spf13/cobra"
 	"os"
 )
 
+// Process exit codes
+const (
+	exitOK            = 0
+	exitFailure       = 1
+	exitNoSolution    = 2
+	exitInvalidPuzzle = 3
+	exitTimeout       = 4
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "sudoku",
 	Short: "A modern Sudoku CLI for generating and solving puzzles",
 	Long:  `Sudoku is a modern command-line tool for generating and solving Sudoku puzzles with customizable difficulty and reproducible results.`,
+
+	// Execute reports errors itself
+	SilenceErrors: true,
+}
+
+// exitError carries a specific process exit code alongside an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// exitCodeFor maps solver errors to process exit codes.
+func exitCodeFor(err error) int {
+	switch {
+	case errors.Is(err, solver.ErrNoSolution):
+		return exitNoSolution
+	case errors.Is(err, solver.ErrInvalidPuzzle):
+		return exitInvalidPuzzle
+	case errors.Is(err, solver.ErrTimeout):
+		return exitTimeout
+	default:
+		return exitFailure
+	}
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+
+		var ee *exitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.code)
+		}
+		os.Exit(exitFailure)
 	}
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"math/rand"
+)
+
+var (
+	shuffleFile  string
+	shuffleCount int
+	shuffleSeed  int64
+)
+
+func init() {
+	shuffleCmd := &cobra.Command{
+		Use:   "shuffle [puzzle...]",
+		Short: "Turn Sudoku puzzles into equivalent puzzles that look different",
+		Long: `Print random puzzles equivalent to each given puzzle, made by relabeling the
+digits, reordering bands, stacks and the rows and columns within them, and
+transposing. Equivalent puzzles take the same techniques to solve, so one rated
+puzzle can be turned into many of the same difficulty. Variant rules and clues
+are not supported.
+
+Puzzles are read like in 'sudoku solve': from the arguments, a file, or stdin.
+
+Examples:
+  sudoku shuffle 53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79
+  sudoku shuffle -n 1000 --seed 1 --output line < rated.txt`,
+		RunE: runShuffle,
+
+		// Puzzles that cannot be transformed are not usage mistakes
+		SilenceUsage: true,
+	}
+
+	shuffleCmd.Flags().StringVarP(&shuffleFile, "file", "f", "", "Read puzzles from a file, one per line ('-' for stdin)")
+	shuffleCmd.Flags().IntVarP(&shuffleCount, "number", "n", 1, "Number of equivalent puzzles to print for each puzzle")
+	shuffleCmd.Flags().Int64Var(&shuffleSeed, "seed", 0, "Seed for reproducible output (0 = random)")
+
+	rootCmd.AddCommand(shuffleCmd)
+}
+
+func runShuffle(cmd *cobra.Command, args []string) error {
+	out, err := newOutput(cmd)
+	if err != nil {
+		return err
+	}
+	puzzles, err := readPuzzles(args, shuffleFile, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(puzzles) == 0 {
+		return errors.New("no puzzles given")
+	}
+
+	// Pick a seed up front and record it, so that the output can be reproduced
+	master := shuffleSeed
+	for master == 0 {
+		master = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(master))
+
+	return forEachPuzzle(cmd, puzzles, "shuffled", func(puzzle string) error {
+		b, err := parsePuzzle(puzzle)
+		if err != nil {
+			return err
+		}
+		solution := quickSolution(b)
+
+		for i := 0; i < shuffleCount; i++ {
+			shuffled, t, err := b.RandomTransform(rng)
+			if err != nil {
+				return err
+			}
+
+			rec := newRecord(shuffled, nil)
+			rec.Seed = master
+			if solution != nil {
+				if s, err := t.Apply(solution); err == nil {
+					rec.Solution = s.GridString()
+				}
+			}
+			if err := out.write(rec, func(w io.Writer) {
+				fmt.Fprintln(w, shuffled)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/solver"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	solveFile    string
+	solveCompact bool
+	solveTimeout time.Duration
+	solveAlgo    string
+)
+
+func init() {
+	solveCmd := &cobra.Command{
+		Use:   "solve [puzzle...]",
+		Short: "Solve Sudoku puzzles",
+		Long: `Solve one or more Sudoku puzzles given as 81-character strings, or 16 to 625
+characters for other grid sizes. Use '.' or '0' for empty cells. Puzzles are read
+from the arguments, from a file with one puzzle per line, or from stdin when neither is given.
+
+Killer cages follow the grid, each introduced by '|' and listing its cells and sum,
+e.g. "|cage:r1c1+r1c2=10". Relations between two adjacent cells are given the same way:
+Kropki dots "|white:r1c1+r1c2" and "|black:r1c1+r1c2", XV sums "|x:r1c1+r1c2" and
+"|v:r1c1+r1c2", and "|gt:r1c1+r1c2" for r1c1 greater than r1c2. "|nonconsecutive"
+rules out consecutive digits in every pair of adjacent cells. Lines list their cells
+from the bulb, as in "|thermo:r1c1+r2c1+r3c2" and "|arrow:r5c5+r4c4+r3c3", and outside
+clues give their sum, as in "|sandwich:r3=15" and "|little-killer:r1c2+r2c3+r3c4=12".
+
+Multi-grid puzzles start with the name of their layout, twodoku, butterfly or samurai,
+and a colon, followed by the cells of the grids row by row across the whole layout,
+each shared cell once, e.g. "samurai:.9...6.3...". They are always solved with dlx.
+
+Exit codes:
+  0  all puzzles solved
+  1  usage or I/O error
+  2  a puzzle has no solution
+  3  a puzzle is malformed or violates Sudoku constraints
+  4  the solver timed out on a puzzle
+
+Examples:
+  sudoku solve 53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79
+  sudoku solve --file puzzles.txt --compact
+  cat puzzles.txt | sudoku solve --timeout 5s --algorithm dlx`,
+		RunE: runSolve,
+
+		// Unsolvable puzzles are not usage mistakes
+		SilenceUsage: true,
+	}
+
+	solveCmd.Flags().StringVarP(&solveFile, "file", "f", "", "Read puzzles from a file, one per line ('-' for stdin)")
+	solveCmd.Flags().BoolVar(&solveCompact, "compact", false, "Print solutions as strings of one character per cell")
+	solveCmd.Flags().DurationVar(&solveTimeout, "timeout", 10*time.Second, "Solving timeout per puzzle")
+	solveCmd.Flags().StringVar(&solveAlgo, "algorithm", "backtracking", "Search algorithm: backtracking or dlx")
+
+	rootCmd.AddCommand(solveCmd)
+}
+
+func runSolve(cmd *cobra.Command, args []string) error {
+	out, err := newOutput(cmd)
+	if err != nil {
+		return err
+	}
+	algorithm, err := solver.ParseAlgorithm(solveAlgo)
+	if err != nil {
+		return err
+	}
+	puzzles, err := readPuzzles(args, solveFile, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(puzzles) == 0 {
+		return errors.New("no puzzles given")
+	}
+
+	return forEachPuzzle(cmd, puzzles, "solved", func(puzzle string) error {
+		if m, ok, err := parseMultiPuzzle(puzzle); ok {
+			if err != nil {
+				return err
+			}
+			return solveMulti(out, m)
+		}
+
+		b, err := parsePuzzle(puzzle)
+		if err != nil {
+			return err
+		}
+		solution, err := solvePuzzle(b, algorithm)
+		if err != nil {
+			return err
+		}
+
+		return out.write(newRecord(b, solution), func(w io.Writer) {
+			if solveCompact {
+				fmt.Fprintln(w, solution.GridString())
+			} else {
+				fmt.Fprintln(w, solution.Format())
+			}
+		})
+	})
+}
+
+// forEachPuzzle calls fn on every puzzle, reporting failures on stderr as it goes.
+// The first failing puzzle decides the exit code, the rest are still processed.
+func forEachPuzzle(cmd *cobra.Command, puzzles []string, verb string, fn func(puzzle string) error) error {
+	failed, code := 0, exitOK
+	for i, puzzle := range puzzles {
+		if err := fn(puzzle); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "puzzle %d: %v\n", i+1, err)
+			if failed == 0 {
+				code = exitCodeFor(err)
+			}
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return &exitError{
+			code: code,
+			err:  fmt.Errorf("%d of %d puzzles could not be %s", failed, len(puzzles), verb),
+		}
+	}
+	return nil
+}
+
+// solvePuzzle solves a single puzzle with the selected algorithm.
+func solvePuzzle(b *board.Board, algorithm solver.Algorithm) (*board.Board, error) {
+	opts := solver.DefaultOptions()
+	opts.Timeout = solveTimeout
+	opts.Algorithm = algorithm
+	return solver.New(b, opts).Solve()
+}
+
+// solveMulti solves and prints a single multi-grid puzzle.
+func solveMulti(out *output, m *board.Multi) error {
+	opts := solver.DefaultOptions()
+	opts.Timeout = solveTimeout
+	solution, err := solver.NewMulti(m, opts).Solve()
+	if err != nil {
+		return err
+	}
+
+	return out.write(newMultiRecord(m, solution), func(w io.Writer) {
+		if solveCompact {
+			fmt.Fprintln(w, solution.GridString())
+		} else {
+			fmt.Fprintln(w, solution.Format())
+		}
+	})
+}
+
+// readPuzzles collects puzzle strings from args, a file, or stdin, in that order of preference.
+// Blank lines and lines starting with '#' are skipped.
+func readPuzzles(args []string, file string, stdin io.Reader) ([]string, error) {
+	if len(args) > 0 {
+		if file != "" {
+			return nil, errors.New("cannot read puzzles from both arguments and --file")
+		}
+		return args, nil
+	}
+
+	r := stdin
+	if file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var puzzles []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		puzzles = append(puzzles, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading puzzles: %w", err)
+	}
+
+	return puzzles, nil
+}
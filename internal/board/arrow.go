@@ -0,0 +1,85 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// arrowKind names arrows in puzzle strings.
+const arrowKind = "arrow"
+
+// Arrow is a line of cells from a circled bulb, whose digit is the sum of the digits along the shaft.
+// Digits may repeat along the shaft where no unit forbids it.
+type Arrow struct {
+	geo   *Geometry
+	cells []int
+}
+
+// NewArrow creates an arrow along the given cells, the first being the bulb.
+// Returns an error if the cells do not form a line or the shaft cannot add up to a single digit.
+func NewArrow(g *Geometry, cells []int) (*Arrow, error) {
+	if err := checkLine(arrowKind, cells, g); err != nil {
+		return nil, err
+	}
+	a := &Arrow{geo: g, cells: slices.Clone(cells)}
+	if len(cells) < 2 || len(cells) > g.size {
+		return nil, fmt.Errorf("%w: %s has %d cells, want 2-%d", ErrInvalidConstraint, a, len(cells), g.size)
+	}
+	return a, nil
+}
+
+// parseArrow decodes the spec of an arrow clause, e.g. "r1c1+r1c2+r2c3" with the bulb first.
+func parseArrow(spec string, g *Geometry) (*Arrow, error) {
+	cells, err := parseCells(spec, g)
+	if err != nil {
+		return nil, err
+	}
+	return NewArrow(g, cells)
+}
+
+// Cells returns the cells of the arrow, the bulb first.
+func (a *Arrow) Cells() []int {
+	return a.cells
+}
+
+// Check reports whether the digits placed on the shaft leave room for its empty cells
+// within the bulb's digit, or within the largest digit while the bulb is empty.
+func (a *Arrow) Check(b *Board) bool {
+	sum, empty := 0, 0
+	for _, pos := range a.cells[1:] {
+		if val := b.cells[pos]; val != EmptyCell {
+			sum += val
+		} else {
+			empty++
+		}
+	}
+	bulb := b.cells[a.cells[0]]
+	if bulb == EmptyCell {
+		return sum+empty <= a.geo.size
+	}
+	return sum+empty <= bulb && bulb <= sum+empty*a.geo.size
+}
+
+// Candidates keeps the digits of pos that fit the range of totals the rest of the arrow allows.
+func (a *Arrow) Candidates(b *Board, pos int, mask uint) uint {
+	lo, hi, ok := b.sumRange(a.cells[1:], pos)
+	if !ok {
+		return 0
+	}
+	if pos == a.cells[0] {
+		return mask & digitsBetween(lo, hi, a.geo.size)
+	}
+
+	bulb := b.options(a.cells[0])
+	if bulb == 0 {
+		return 0
+	}
+	least, most := bits.TrailingZeros(bulb)+1, bits.Len(bulb)
+	return mask & digitsBetween(least-hi, most-lo, a.geo.size)
+}
+
+// String encodes the arrow as a puzzle string clause, e.g. "arrow:r1c1+r1c2+r2c3".
+func (a *Arrow) String() string {
+	return fmt.Sprintf("%s:%s", arrowKind, formatCells(a.cells, a.geo))
+}
@@ -2,80 +2,234 @@ package board
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 // Special cell values
 const (
 	EmptyCell   = 0
 	InvalidCell = -1
-	CellCount   = 81
 )
 
-// Bitmask values
-const (
-	allNine = 511
-)
-
-// Board represents a 9x9 Sudoku board.
+// Board represents a Sudoku board of any supported geometry.
 type Board struct {
-	cells [CellCount]int
+	geo   *Geometry
+	cells []int
 
-	// Bitmasks track placed digits in each unit (row/col/box).
+	// Bitmasks track placed digits in each unit, indexed like Geometry.Units.
 	// Bit i represents digit i+1 (bit 0 = digit 1, bit 8 = digit 9).
 	// This allows for O(1) validation.
-	rowMasks [9]uint
-	colMasks [9]uint
-	boxMasks [9]uint
+	unitMasks []uint
+
+	// marks stores pencil marks as one candidate bitmask per cell.
+	// Unlike the unit masks they are edited explicitly, so eliminations persist.
+	// Set and Clear leave them untouched.
+	marks []uint
 
 	// emptyCount tracks unfilled cells for quick completion checks.
 	// Once initialized, emptyCount should only be touched inside Set and Clear.
 	emptyCount int
+
+	// constraints holds rules beyond the units, such as killer cages (nil = none).
+	constraints *constraintSet
 }
 
-// New creates an empty Board.
+// New creates an empty standard 9x9 Board.
 func New() *Board {
+	return NewWithGeometry(Standard)
+}
+
+// NewWithGeometry creates an empty Board of the given geometry.
+func NewWithGeometry(g *Geometry) *Board {
 	b := &Board{
-		emptyCount: CellCount,
+		geo:        g,
+		cells:      make([]int, g.cells),
+		unitMasks:  make([]uint, len(g.units)),
+		marks:      make([]uint, g.cells),
+		emptyCount: g.cells,
 	}
+	b.ResetCandidates()
 	return b
 }
 
-// NewFromString creates a Board from an 81-character string.
-// Use '.' or '0' for empty cells, '1'-'9' for filled cells.
+// NewFromString creates a Board from a puzzle string, inferring the grid size from its length.
+// Compact strings hold one character per cell: '.' or '0' for empty cells,
+// and '1'-'9' then letters from 'A' in either case for filled cells, so "G" is 16.
+// Strings of whitespace or comma separated tokens may also spell digits as numbers, e.g. "16".
+// Rectangular grids get the box shape given by GeometryFor.
+//
+// The grid may be followed by clauses, each introduced by '|': a jigsaw region map
+// such as "|regions:111222333...", with one symbol per cell naming its region,
+// extra units such as "|x" for X-Sudoku, rules such as "|anti-knight", and constraints such as the killer cage "|cage:r1c1+r1c2=10".
+// Relations between adjacent cells take the kind "white" or "black" for Kropki dots, "x" or "v"
+// for XV sums, or "gt" for the first cell being greater, e.g. "|white:r1c1+r1c2",
+// and "|nonconsecutive" rules out consecutive digits in all adjacent cells.
+// Thermometers and arrows list their cells from the bulb, e.g. "|thermo:r1c1+r2c1+r3c2",
+// and sandwich and little killer clues give a sum, e.g. "|sandwich:r3=15".
 func NewFromString(s string) (*Board, error) {
-	if len(s) != CellCount {
-		return nil, fmt.Errorf("string must be exactly %d characters, got %d", CellCount, len(s))
-	}
-
-	b := New()
-	for pos := 0; pos < CellCount; pos++ {
-		ch := s[pos]
-		switch ch {
-		case '.', '0':
-			// Empty cell, already initialized
-		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			val := int(ch - '0')
-			if err := b.Set(pos, val); err != nil {
-				return nil, fmt.Errorf("invalid board at position %d: %w", pos, err)
+	grid, clauses, _ := strings.Cut(s, "|")
+	tokens := tokenize(grid)
+	for _, size := range Sizes() {
+		if len(tokens) == size*size {
+			return parsePuzzle(tokens, clauses, defaultGeometries[size])
+		}
+	}
+	return nil, fmt.Errorf("%w: %d cells, want the square of one of %v", ErrUnsupportedSize, len(tokens), Sizes())
+}
+
+// NewFromStringWithGeometry is like NewFromString for a grid of a known geometry,
+// such as 6x6 with 3x2 boxes.
+func NewFromStringWithGeometry(s string, g *Geometry) (*Board, error) {
+	grid, clauses, _ := strings.Cut(s, "|")
+	tokens := tokenize(grid)
+	if len(tokens) != g.cells {
+		return nil, fmt.Errorf("%s puzzle must have exactly %d cells, got %d", g, g.cells, len(tokens))
+	}
+	return parsePuzzle(tokens, clauses, g)
+}
+
+// parsePuzzle fills a new board of geometry g with one token per cell,
+// after applying the '|' separated clauses that follow the grid.
+// Constraints go first so that the digits are checked against them.
+func parsePuzzle(tokens []string, clauses string, g *Geometry) (*Board, error) {
+	var constraints []string
+	if clauses != "" {
+		var extras ExtraUnits
+		var rules Rules
+		for _, clause := range strings.Split(clauses, "|") {
+			if spec, ok := strings.CutPrefix(clause, regionsKind+":"); ok {
+				jigsaw, err := parseRegions(spec)
+				if err != nil {
+					return nil, err
+				}
+				if jigsaw.size != g.size {
+					return nil, fmt.Errorf("%w: %dx%d regions for a %dx%d grid", ErrInvalidRegions, jigsaw.size, jigsaw.size, g.size, g.size)
+				}
+				g = jigsaw
+				continue
+			}
+			if flag, ok := parseExtraUnit(clause); ok {
+				extras |= flag
+				continue
 			}
-		default:
-			return nil, fmt.Errorf("invalid character '%c' at position %d", ch, pos)
+			if flag, ok := parseRule(clause); ok {
+				rules |= flag
+				continue
+			}
+			constraints = append(constraints, clause)
+		}
+		var err error
+		if g, err = g.WithExtraUnits(extras); err != nil {
+			return nil, err
+		}
+		if g, err = g.WithRules(rules); err != nil {
+			return nil, err
+		}
+	}
+
+	b := NewWithGeometry(g)
+	for _, clause := range constraints {
+		c, err := parseConstraint(clause, g)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.AddConstraint(c); err != nil {
+			return nil, err
 		}
 	}
+	if err := b.parseTokens(tokens); err != nil {
+		return nil, err
+	}
 	return b, nil
 }
 
+// tokenize splits a puzzle string into cells: on whitespace and commas if it has any,
+// or into single characters otherwise.
+func tokenize(s string) []string {
+	isSeparator := func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	}
+	if strings.ContainsFunc(s, isSeparator) {
+		return strings.FieldsFunc(s, isSeparator)
+	}
+	return strings.Split(s, "")
+}
+
+// parseTokens places one token per cell on the board.
+func (b *Board) parseTokens(tokens []string) error {
+	for pos, tok := range tokens {
+		val, ok := parseDigit(tok)
+		if !ok || val > b.geo.size {
+			if len(tok) == 1 {
+				return fmt.Errorf("invalid character '%s' at position %d", tok, pos)
+			}
+			return fmt.Errorf("invalid cell %q at position %d", tok, pos)
+		}
+		if val == EmptyCell {
+			continue
+		}
+		if err := b.Set(pos, val); err != nil {
+			return fmt.Errorf("invalid board at position %d: %w", pos, err)
+		}
+	}
+	return nil
+}
+
+// parseDigit reads a cell token: '.' or '0' for an empty cell, a symbol, or a decimal number.
+func parseDigit(tok string) (int, bool) {
+	if tok == "." {
+		return EmptyCell, true
+	}
+	if len(tok) == 1 {
+		if i := strings.IndexByte(symbols, byte(unicode.ToUpper(rune(tok[0])))); i >= 0 {
+			return i + 1, true
+		}
+	}
+	val, err := strconv.Atoi(tok)
+	return val, err == nil && val >= 0
+}
+
 // Clone creates an independent copy of the Board.
 func (b *Board) Clone() *Board {
 	if b == nil {
 		return nil
 	}
 	clone := *b
+	clone.cells = append([]int(nil), b.cells...)
+	clone.unitMasks = append([]uint(nil), b.unitMasks...)
+	clone.marks = append([]uint(nil), b.marks...)
 	return &clone
 }
 
-// Set attempts to place a value 1-9 at the given position.
+// CopyFrom overwrites the board with the contents of another board of the same geometry,
+// reusing its storage.
+func (b *Board) CopyFrom(other *Board) {
+	b.geo = other.geo
+	b.cells = append(b.cells[:0], other.cells...)
+	b.unitMasks = append(b.unitMasks[:0], other.unitMasks...)
+	b.marks = append(b.marks[:0], other.marks...)
+	b.emptyCount = other.emptyCount
+	b.constraints = other.constraints
+}
+
+// Geometry returns the shape of the board.
+func (b *Board) Geometry() *Geometry {
+	return b.geo
+}
+
+// Size returns the number of digits, which is also the length of a row, column or box.
+func (b *Board) Size() int {
+	return b.geo.size
+}
+
+// CellCount returns the number of cells on the board.
+func (b *Board) CellCount() int {
+	return b.geo.cells
+}
+
+// Set attempts to place a digit at the given position.
 // Returns an error if the placement violates Sudoku rules or parameters are invalid.
 func (b *Board) Set(pos, val int) error {
 	if err := b.validatePosition(pos); err != nil {
@@ -91,26 +245,30 @@ func (b *Board) Set(pos, val int) error {
 		b.Clear(pos)
 	}
 
-	row, col, box := posToRow[pos], posToCol[pos], posToBox[pos]
 	mask := uint(1 << (val - 1))
 
 	// Check if value already exists in row, column, or box for Sudoku rules
-	if b.rowMasks[row]&mask != 0 {
-		return fmt.Errorf("%w: value %d already in row %d", ErrIllegalMove, val, row)
-	}
-	if b.colMasks[col]&mask != 0 {
-		return fmt.Errorf("%w: value %d already in column %d", ErrIllegalMove, val, col)
+	for _, u := range b.geo.unitsOf[pos] {
+		if b.unitMasks[u]&mask != 0 {
+			return fmt.Errorf("%w: value %d already in %s", ErrIllegalMove, val, b.geo.units[u])
+		}
 	}
-	if b.boxMasks[box]&mask != 0 {
-		return fmt.Errorf("%w: value %d already in box %d", ErrIllegalMove, val, box)
+	for _, other := range b.geo.movePeers[pos] {
+		if b.cells[other] == val {
+			return fmt.Errorf("%w: value %d already at %s (%s)", ErrIllegalMove, val, b.geo.CellName(other), b.geo.moveRule(pos, other))
+		}
 	}
 
 	// Modify the board only once we know it's legal to do so
-	b.cells[pos] = val
-	b.rowMasks[row] |= mask
-	b.colMasks[col] |= mask
-	b.boxMasks[box] |= mask
-	b.emptyCount--
+	b.SetForce(pos, val)
+
+	// Constraints judge the board as a whole, so check them with the digit in place
+	for _, c := range b.constraintsOf(pos) {
+		if !c.Check(b) {
+			b.Clear(pos)
+			return fmt.Errorf("%w: value %d breaks %s", ErrIllegalMove, val, c)
+		}
+	}
 
 	return nil
 }
@@ -118,13 +276,12 @@ func (b *Board) Set(pos, val int) error {
 // SetForce places a value without validation checks.
 // Use only when certain the move is valid.
 func (b *Board) SetForce(pos, val int) {
-	row, col, box := posToRow[pos], posToCol[pos], posToBox[pos]
 	mask := uint(1 << (val - 1))
 
 	b.cells[pos] = val
-	b.rowMasks[row] |= mask
-	b.colMasks[col] |= mask
-	b.boxMasks[box] |= mask
+	for _, u := range b.geo.unitsOf[pos] {
+		b.unitMasks[u] |= mask
+	}
 	b.emptyCount--
 }
 
@@ -142,13 +299,12 @@ func (b *Board) Clear(pos int) error {
 		return nil
 	}
 
-	row, col, box := posToRow[pos], posToCol[pos], posToBox[pos]
 	mask := uint(1 << (val - 1))
 
 	b.cells[pos] = EmptyCell
-	b.rowMasks[row] &^= mask
-	b.colMasks[col] &^= mask
-	b.boxMasks[box] &^= mask
+	for _, u := range b.geo.unitsOf[pos] {
+		b.unitMasks[u] &^= mask
+	}
 	b.emptyCount++
 
 	return nil
@@ -157,28 +313,95 @@ func (b *Board) Clear(pos int) error {
 // Get returns the value at the given position.
 // Returns InvalidCell for invalid positions.
 func (b *Board) Get(pos int) int {
-	if !isValidPosition(pos) {
+	if !b.isValidPosition(pos) {
 		return InvalidCell
 	}
 	return b.cells[pos]
 }
 
-// GetCandidatesMask returns the bitmask of candidates for a given position.
+// GetCandidatesMask returns the bitmask of candidates for a given position:
+// the stored pencil marks minus any digit already placed in the cell's units or a move away under the rules,
+// narrowed by the constraints on the cell.
 // A returned 0 indicates an unsolvable board or an invalid position.
 func (b *Board) GetCandidatesMask(pos int) uint {
-	if !isValidPosition(pos) {
+	if !b.isValidPosition(pos) {
 		return 0
 	}
-	row, col, box := posToRow[pos], posToCol[pos], posToBox[pos]
-	return allNine &^ b.rowMasks[row] &^ b.colMasks[col] &^ b.boxMasks[box]
+	mask := b.unitCandidates(pos)
+	for _, c := range b.constraintsOf(pos) {
+		mask = c.Candidates(b, pos, mask)
+	}
+	return mask
+}
+
+// unitCandidates returns the stored pencil marks of pos minus the digits placed in its peers:
+// its units, and the cells a move apart under the rules.
+func (b *Board) unitCandidates(pos int) uint {
+	mask := b.marks[pos]
+	for _, u := range b.geo.unitsOf[pos] {
+		mask &^= b.unitMasks[u]
+	}
+	for _, other := range b.geo.movePeers[pos] {
+		if val := b.cells[other]; val != EmptyCell {
+			mask &^= 1 << (val - 1)
+		}
+	}
+	return mask
 }
 
-// GetCandidates returns a slice of candidates 1-9 for a given position.
+// Marks returns the stored pencil marks of a cell, ignoring placed digits.
+// Returns 0 for invalid positions.
+func (b *Board) Marks(pos int) uint {
+	if !b.isValidPosition(pos) {
+		return 0
+	}
+	return b.marks[pos]
+}
+
+// SetMarks replaces the stored pencil marks of a cell.
+// Returns an error if the position is invalid.
+func (b *Board) SetMarks(pos int, mask uint) error {
+	if err := b.validatePosition(pos); err != nil {
+		return err
+	}
+	b.marks[pos] = mask & b.geo.AllDigits()
+	return nil
+}
+
+// EliminateCandidate removes a digit from the stored pencil marks of a cell.
+// Returns an error if the position or value is invalid.
+func (b *Board) EliminateCandidate(pos, val int) error {
+	if err := b.validateCandidate(pos, val); err != nil {
+		return err
+	}
+	b.marks[pos] &^= uint(1 << (val - 1))
+	return nil
+}
+
+// RestoreCandidate adds a digit back to the stored pencil marks of a cell.
+// Returns an error if the position or value is invalid.
+func (b *Board) RestoreCandidate(pos, val int) error {
+	if err := b.validateCandidate(pos, val); err != nil {
+		return err
+	}
+	b.marks[pos] |= uint(1 << (val - 1))
+	return nil
+}
+
+// ResetCandidates discards every elimination,
+// so candidates are once again derived from the placed digits alone.
+func (b *Board) ResetCandidates() {
+	for pos := range b.marks {
+		b.marks[pos] = b.geo.AllDigits()
+	}
+}
+
+// GetCandidates returns a slice of candidate digits for a given position.
 // An empty slice indicates an unsolvable board or an invalid position.
 func (b *Board) GetCandidates(pos int) []int {
 	mask := b.GetCandidatesMask(pos)
-	candidates := make([]int, 0, 9)
-	for num := 1; num <= 9; num++ {
+	candidates := make([]int, 0, b.geo.size)
+	for num := 1; num <= b.geo.size; num++ {
 		if mask&uint(1<<(num-1)) != 0 {
 			candidates = append(candidates, num)
 		}
@@ -193,79 +416,79 @@ func (b *Board) EmptyCount() int {
 
 // ClueCount returns the number of filled cells on the board.
 func (b *Board) ClueCount() int {
-	return CellCount - b.emptyCount
+	return b.geo.cells - b.emptyCount
 }
 
-// String returns the board as an 81-character string.
-// Empty cells are represented as '.', filled cells as '1'-'9'.
+// String returns the board as a compact string of one character per cell,
+// followed by its extra units and constraints in the form read by NewFromString.
+// Empty cells are represented as '.', filled cells as '1'-'9' then 'A' onwards.
 func (b *Board) String() string {
 	var sb strings.Builder
-	sb.Grow(CellCount)
+	sb.WriteString(b.GridString())
 
-	for _, cell := range b.cells {
-		if cell == EmptyCell {
-			sb.WriteByte('.')
-		} else {
-			sb.WriteByte('0' + byte(cell))
+	if b.geo.Jigsaw() {
+		sb.WriteByte('|')
+		sb.WriteString(b.geo.regionsClause())
+	}
+	for i, name := range extraUnitNames {
+		if b.geo.extras&(1<<i) != 0 {
+			sb.WriteByte('|')
+			sb.WriteString(name)
 		}
 	}
+	for i, name := range ruleNames {
+		if b.geo.rules&(1<<i) != 0 {
+			sb.WriteByte('|')
+			sb.WriteString(name)
+		}
+	}
+
+	for _, c := range b.Constraints() {
+		sb.WriteByte('|')
+		sb.WriteString(c.String())
+	}
 
 	return sb.String()
 }
 
-// Format returns a human-readable board representation with grid lines.
-func (b *Board) Format() string {
+// GridString is like String but leaves out the constraints.
+func (b *Board) GridString() string {
 	var sb strings.Builder
-	line := "+-------+-------+-------+\n"
-	sb.WriteString(line)
-
-	for row := 0; row < 9; row++ {
-		sb.WriteString("| ")
-		for col := 0; col < 9; col++ {
-			val := b.Get(MakePos(row, col))
-			if val == EmptyCell {
-				sb.WriteByte('.')
-			} else {
-				sb.WriteByte('0' + byte(val))
-			}
-			sb.WriteByte(' ')
-
-			if (col+1)%3 == 0 {
-				sb.WriteString("| ")
-			}
-		}
-		sb.WriteString("\n")
+	sb.Grow(len(b.cells))
 
-		if (row+1)%3 == 0 {
-			sb.WriteString(line)
-		}
+	for _, cell := range b.cells {
+		sb.WriteByte(symbol(cell))
 	}
 
 	return sb.String()
 }
 
-// Precomputed lookup tables for position mapping
-var (
-	posToRow [CellCount]int
-	posToCol [CellCount]int
-	posToBox [CellCount]int
-)
+// Format returns a human-readable board representation with grid lines.
+func (b *Board) Format() string {
+	var sb strings.Builder
+	g := b.geo
+	sb.WriteString(g.Draw(func(pos int) string {
+		return string(symbol(b.cells[pos]))
+	}))
 
-// MakePos transforms a row and column into a linear position.
-// Returns InvalidCell if row and/or col are invalid.
-func MakePos(row, col int) int {
-	if row < 0 || row >= 9 || col < 0 || col >= 9 {
-		return InvalidCell
+	if g.extras != 0 {
+		fmt.Fprintf(&sb, "extra units: %s\n", g.extras)
+	}
+	if g.rules != 0 {
+		fmt.Fprintf(&sb, "rules: %s\n", g.rules)
+	}
+	for _, c := range b.Constraints() {
+		sb.WriteString(c.String())
+		sb.WriteString("\n")
 	}
-	return 9*row + col
+
+	return sb.String()
 }
 
-// init initializes lookup tables for position-to-unit mappings.
-// Should be called once at program start.
-func init() {
-	for pos := 0; pos < CellCount; pos++ {
-		posToRow[pos] = int(pos / 9)
-		posToCol[pos] = int(pos % 9)
-		posToBox[pos] = 3*int(pos/27) + int((pos%9)/3)
+// symbol returns the character representing a cell value.
+func symbol(val int) byte {
+	if val == EmptyCell {
+		return '.'
 	}
+	return symbols[val-1]
 }
@@ -0,0 +1,164 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cageKind names cages in puzzle strings.
+const cageKind = "cage"
+
+// Cage is a killer cage: its cells hold distinct digits that add up to its sum.
+type Cage struct {
+	geo   *Geometry
+	sum   int
+	cells []int
+}
+
+// NewCage creates a cage over the given cells of a grid.
+// Returns an error if there are more cells than digits, a cell repeats, or no digits can make the sum.
+func NewCage(g *Geometry, sum int, cells []int) (*Cage, error) {
+	c := &Cage{geo: g, sum: sum, cells: slices.Clone(cells)}
+	if len(cells) == 0 || len(cells) > g.size {
+		return nil, fmt.Errorf("%w: %s has %d cells, want 1-%d", ErrInvalidConstraint, c, len(cells), g.size)
+	}
+	for i, pos := range cells {
+		if pos < 0 || pos >= g.cells {
+			return nil, fmt.Errorf("%w: %s: position %d out of bounds", ErrInvalidConstraint, c, pos)
+		}
+		if slices.Contains(cells[:i], pos) {
+			return nil, fmt.Errorf("%w: %s repeats %s", ErrInvalidConstraint, c, g.CellName(pos))
+		}
+	}
+	if !reachable(len(cells), sum, g.AllDigits()) {
+		return nil, fmt.Errorf("%w: %s: no %d distinct digits add up to %d", ErrInvalidConstraint, c, len(cells), sum)
+	}
+	return c, nil
+}
+
+// parseCage decodes the spec of a cage clause, e.g. "r1c1+r1c2=10".
+func parseCage(spec string, g *Geometry) (*Cage, error) {
+	names, sum, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: cage %q has no sum", ErrInvalidConstraint, spec)
+	}
+	total, err := strconv.Atoi(sum)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cage %q has an invalid sum", ErrInvalidConstraint, spec)
+	}
+	cells, err := parseCells(names, g)
+	if err != nil {
+		return nil, err
+	}
+	return NewCage(g, total, cells)
+}
+
+// Sum returns the total of the digits in the cage.
+func (c *Cage) Sum() int {
+	return c.sum
+}
+
+// Cells returns the cells of the cage.
+func (c *Cage) Cells() []int {
+	return c.cells
+}
+
+// Check reports whether the placed digits are distinct and leave a reachable sum for the empty cells.
+func (c *Cage) Check(b *Board) bool {
+	used, rem, empty, ok := c.placed(b, InvalidCell)
+	return ok && reachable(len(empty), rem, b.geo.AllDigits()&^used)
+}
+
+// Candidates keeps the digits of pos that belong to some combination of distinct digits
+// making up the rest of the sum, with every other empty cell taking one of its own candidates.
+func (c *Cage) Candidates(b *Board, pos int, mask uint) uint {
+	used, rem, empty, ok := c.placed(b, pos)
+	if !ok {
+		return 0
+	}
+
+	others := make([]uint, 0, len(empty))
+	for _, cell := range empty {
+		if cell != pos {
+			others = append(others, b.unitCandidates(cell))
+		}
+	}
+
+	var allowed uint
+	for m := mask &^ used; m != 0; m &= m - 1 {
+		bit := m & -m
+		val := bits.TrailingZeros(bit) + 1
+		if fillable(others, used|bit, rem-val, b.geo.AllDigits()) {
+			allowed |= bit
+		}
+	}
+	return allowed
+}
+
+// placed returns the digits placed in the cage outside skip, the sum left for its
+// empty cells, and those cells. ok is false if a digit repeats.
+func (c *Cage) placed(b *Board, skip int) (used uint, rem int, empty []int, ok bool) {
+	rem = c.sum
+	for _, pos := range c.cells {
+		val := b.cells[pos]
+		if val == EmptyCell || pos == skip {
+			empty = append(empty, pos)
+			continue
+		}
+		bit := uint(1 << (val - 1))
+		if used&bit != 0 {
+			return used, rem, empty, false
+		}
+		used |= bit
+		rem -= val
+	}
+	return used, rem, empty, true
+}
+
+// String encodes the cage as a puzzle string clause, e.g. "cage:r1c1+r1c2=10".
+func (c *Cage) String() string {
+	return fmt.Sprintf("%s:%s=%d", cageKind, formatCells(c.cells, c.geo), c.sum)
+}
+
+// fillable reports whether cells with the given candidate masks can take distinct digits,
+// none of them in used, that add up to rem.
+func fillable(masks []uint, used uint, rem int, all uint) bool {
+	if len(masks) == 0 {
+		return rem == 0
+	}
+	if !reachable(len(masks), rem, all&^used) {
+		return false
+	}
+	for m := masks[0] &^ used; m != 0; m &= m - 1 {
+		bit := m & -m
+		val := bits.TrailingZeros(bit) + 1
+		if val > rem {
+			break
+		}
+		if fillable(masks[1:], used|bit, rem-val, all) {
+			return true
+		}
+	}
+	return false
+}
+
+// reachable reports whether rem lies between the sums of the n smallest and n largest digits of avail,
+// which any n distinct digits of avail must add up to.
+func reachable(n, rem int, avail uint) bool {
+	if bits.OnesCount(avail) < n {
+		return false
+	}
+	lo, hi := 0, 0
+	low, high := avail, avail
+	for range n {
+		lo += bits.TrailingZeros(low) + 1
+		low &= low - 1
+		top := bits.Len(high)
+		hi += top
+		high &^= 1 << (top - 1)
+	}
+	return lo <= rem && rem <= hi
+}
@@ -0,0 +1,263 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrNotCanonicalizable = errors.New("puzzle cannot be canonicalized")
+
+// maxCanonicalSize is the largest grid Canonicalize searches.
+// A 16x16 grid already has over 15 million column orders to try against its rows.
+const maxCanonicalSize = 12
+
+// Canonicalize returns the minlex form of the puzzle: the smallest grid, read cell by cell
+// with empty cells lowest, among all the puzzles it can be turned into without changing
+// its solutions' structure. Those transforms relabel the digits, reorder the bands and
+// the rows within each band, reorder the stacks and the columns within each stack,
+// and transpose grids with square boxes; for 9x9 they number about 3.36 million before relabeling.
+// Two puzzles are equivalent exactly when their minlex forms are equal.
+// Returns an error for jigsaw regions, extra units, rules, constraints, and grids larger than 12x12.
+func (b *Board) Canonicalize() (*Board, error) {
+	g := b.geo
+	switch {
+	case !b.transformable():
+		return nil, fmt.Errorf("%w: %s puzzles change under transforms", ErrNotCanonicalizable, g)
+	case g.size > maxCanonicalSize:
+		return nil, fmt.Errorf("%w: %s grids have too many transforms to search", ErrNotCanonicalizable, g)
+	}
+
+	m := &minlex{
+		g:     g,
+		grid:  make([][]int, g.size),
+		best:  make([]int, g.cells),
+		cur:   make([]int, g.cells),
+		order: make([]int, g.size),
+		used:  make([]bool, g.size),
+	}
+	for row := range m.grid {
+		m.grid[row] = make([]int, g.size)
+	}
+
+	transposes := []bool{false}
+	if g.boxRows == g.boxCols {
+		transposes = append(transposes, true)
+	}
+	for _, transpose := range transposes {
+		for _, cols := range columnOrders(g) {
+			for row := 0; row < g.size; row++ {
+				for col, from := range cols {
+					if transpose {
+						m.grid[row][col] = b.cells[g.MakePos(from, row)]
+					} else {
+						m.grid[row][col] = b.cells[g.MakePos(row, from)]
+					}
+				}
+			}
+			m.search(0, labeling{}, false)
+		}
+	}
+
+	canonical := NewWithGeometry(g)
+	for pos, val := range m.best {
+		if val != EmptyCell {
+			canonical.SetForce(pos, val)
+		}
+	}
+	return canonical, nil
+}
+
+// Equivalent reports whether two puzzles of the same geometry turn into each other
+// under the transforms of Canonicalize.
+func Equivalent(a, b *Board) (bool, error) {
+	if a.geo.size != b.geo.size || a.geo.boxRows != b.geo.boxRows {
+		return false, nil
+	}
+	ca, err := a.Canonicalize()
+	if err != nil {
+		return false, err
+	}
+	cb, err := b.Canonicalize()
+	if err != nil {
+		return false, err
+	}
+	return slices.Equal(ca.cells, cb.cells), nil
+}
+
+// columnOrders returns every order of the columns that keeps each stack together,
+// each as the old column of every new column.
+func columnOrders(g *Geometry) [][]int {
+	stacks := g.size / g.boxCols
+	stackOrders := permutations(stacks)
+	inStack := permutations(g.boxCols)
+
+	var orders [][]int
+	var build func(order []int, stack int, stackOrder []int)
+	build = func(order []int, stack int, stackOrder []int) {
+		if stack == stacks {
+			orders = append(orders, slices.Clone(order))
+			return
+		}
+		for _, p := range inStack {
+			for i, c := range p {
+				order[stack*g.boxCols+i] = stackOrder[stack]*g.boxCols + c
+			}
+			build(order, stack+1, stackOrder)
+		}
+	}
+	for _, stackOrder := range stackOrders {
+		build(make([]int, g.size), 0, stackOrder)
+	}
+	return orders
+}
+
+// permutations returns every order of 0 to n-1.
+func permutations(n int) [][]int {
+	if n == 0 {
+		return [][]int{{}}
+	}
+	var perms [][]int
+	for _, p := range permutations(n - 1) {
+		for i := 0; i <= len(p); i++ {
+			perm := slices.Insert(slices.Clone(p), i, n-1)
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// labeling maps original digits to new ones, in order of first appearance.
+type labeling struct {
+	to   [MaxSize + 1]int // New digit of each original digit, EmptyCell if not seen yet
+	next int              // Last new digit handed out
+}
+
+// relabel returns the new digits of a row, labeling the digits it sees for the first time,
+// along with the extended labeling.
+func (l labeling) relabel(row []int, out []int) labeling {
+	for i, val := range row {
+		if val != EmptyCell && l.to[val] == EmptyCell {
+			l.next++
+			l.to[val] = l.next
+		}
+		out[i] = l.to[val]
+	}
+	return l
+}
+
+// minlex searches the row orders of a grid whose columns are already in place
+// for the smallest relabeled grid, keeping the best found across searches.
+type minlex struct {
+	g       *Geometry
+	grid    [][]int // Rows of the grid being searched, in their original order
+	best    []int   // Smallest grid found so far
+	found   bool
+	updates int    // Number of times best has changed
+	cur     []int  // Relabeled rows of the order being built
+	order   []int  // Original row placed at each depth
+	used    []bool // Rows already placed
+}
+
+// search places the row at the given depth, trying only the rows that relabel smallest,
+// and records the grid once all are placed. less reports whether the rows placed so far
+// are already smaller than the same rows of best.
+func (m *minlex) search(depth int, labels labeling, less bool) {
+	size := m.g.size
+	if depth == size {
+		if !m.found || less {
+			copy(m.best, m.cur)
+			m.found = true
+			m.updates++
+		}
+		return
+	}
+
+	var row, low [MaxSize]int
+	var ties []int
+	var tieLabels []labeling
+	for _, r := range m.candidates(depth) {
+		next := labels.relabel(m.grid[r], row[:size])
+		switch c := slices.Compare(row[:size], low[:size]); {
+		case len(ties) == 0 || c < 0:
+			low = row
+			ties, tieLabels = ties[:0], tieLabels[:0]
+			fallthrough
+		case c == 0:
+			ties = append(ties, r)
+			tieLabels = append(tieLabels, next)
+		}
+	}
+
+	if m.found && !less {
+		switch slices.Compare(low[:size], m.best[depth*size:(depth+1)*size]) {
+		case 1:
+			return
+		case -1:
+			less = true
+		}
+	}
+	copy(m.cur[depth*size:], low[:size])
+
+	for i, r := range ties {
+		updates := m.updates
+		m.used[r], m.order[depth] = true, r
+		m.search(depth+1, tieLabels[i], less)
+		m.used[r] = false
+
+		// A new best runs through this row, so the rows placed so far now equal its own
+		if m.updates != updates {
+			less = false
+		}
+	}
+}
+
+// candidates returns the rows that may go at the given depth: the unused rows of the
+// band being placed, or the rows of the unused bands when a new band starts.
+// Of rows that would give the same grid wherever they go, only the first is returned.
+func (m *minlex) candidates(depth int) []int {
+	g := m.g
+	var rows []int
+	if offset := depth % g.boxRows; offset != 0 {
+		band := m.order[depth-offset] / g.boxRows
+		for r := band * g.boxRows; r < (band+1)*g.boxRows; r++ {
+			if !m.used[r] && !slices.ContainsFunc(rows, func(other int) bool {
+				return slices.Equal(m.grid[r], m.grid[other])
+			}) {
+				rows = append(rows, r)
+			}
+		}
+		return rows
+	}
+
+	for r := 0; r < g.size; r++ {
+		if m.used[r] || slices.ContainsFunc(rows, func(other int) bool {
+			return m.interchangeable(r, other)
+		}) {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// interchangeable reports whether two unused rows, each starting a band, lead to the same grids:
+// they hold the same digits and, if in different bands, so do their bands row for row.
+func (m *minlex) interchangeable(a, b int) bool {
+	if !slices.Equal(m.grid[a], m.grid[b]) {
+		return false
+	}
+	h := m.g.boxRows
+	if a/h == b/h {
+		return true
+	}
+	if a%h != b%h {
+		return false
+	}
+	for i := 0; i < h; i++ {
+		if !slices.Equal(m.grid[a/h*h+i], m.grid[b/h*h+i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidConstraint = errors.New("invalid constraint")
+
+// Constraint is a rule over a group of cells beyond the units of the geometry,
+// such as a killer cage, a Kropki dot or a thermometer. Constraints are immutable once created.
+type Constraint interface {
+	// Cells returns the cells the constraint applies to.
+	// The returned slice is shared and must not be modified.
+	Cells() []int
+
+	// Check reports whether the digits placed on b can still satisfy the constraint.
+	Check(b *Board) bool
+
+	// Candidates narrows mask, the candidates of pos, to the digits the constraint allows there,
+	// treating pos as empty.
+	Candidates(b *Board, pos int, mask uint) uint
+
+	// String encodes the constraint as a puzzle string clause, e.g. "cage:r1c1+r1c2=10".
+	String() string
+}
+
+// constraintSet holds the constraints of a board, indexed by cell.
+// Clones share it, so it is replaced rather than modified when a constraint is added.
+type constraintSet struct {
+	all []Constraint
+	of  [][]Constraint
+}
+
+// Constraints returns the constraints added to the board, in order.
+// The returned slice is shared and must not be modified.
+func (b *Board) Constraints() []Constraint {
+	if b.constraints == nil {
+		return nil
+	}
+	return b.constraints.all
+}
+
+// constraintsOf returns the constraints that apply to pos.
+func (b *Board) constraintsOf(pos int) []Constraint {
+	if b.constraints == nil {
+		return nil
+	}
+	return b.constraints.of[pos]
+}
+
+// AddConstraint adds a constraint to the board.
+// Returns an error if it covers cells off the board or the digits already placed break it.
+func (b *Board) AddConstraint(c Constraint) error {
+	for _, pos := range c.Cells() {
+		if err := b.validatePosition(pos); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidConstraint, c, err)
+		}
+	}
+	if !c.Check(b) {
+		return fmt.Errorf("%w: placed digits break %s", ErrIllegalMove, c)
+	}
+
+	cs := &constraintSet{
+		all: append(slices.Clip(b.Constraints()), c),
+		of:  make([][]Constraint, b.geo.cells),
+	}
+	for _, c := range cs.all {
+		for _, pos := range c.Cells() {
+			cs.of[pos] = append(cs.of[pos], c)
+		}
+	}
+	b.constraints = cs
+	return nil
+}
+
+// parseConstraint decodes a puzzle string clause of the form "<kind>:<spec>",
+// or just "<kind>" for rules that cover the whole grid.
+func parseConstraint(clause string, g *Geometry) (Constraint, error) {
+	kind, spec, _ := strings.Cut(clause, ":")
+	if rk, ok := parseRelationKind(kind); ok {
+		return parseRelation(rk, spec, g)
+	}
+	switch kind {
+	case cageKind:
+		return parseCage(spec, g)
+	case sandwichKind:
+		return parseSandwich(spec, g)
+	case arrowKind:
+		return parseArrow(spec, g)
+	case thermoKind:
+		return parseThermometer(spec, g)
+	case littleKillerKind:
+		return parseLittleKiller(spec, g)
+	case nonConsecutiveKind:
+		if spec != "" {
+			return nil, fmt.Errorf("%w: %s takes no cells, got %q", ErrInvalidConstraint, kind, spec)
+		}
+		return NewNonConsecutive(g), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidConstraint, kind)
+	}
+}
+
+// parseCells decodes cell names joined by '+', e.g. "r1c1+r1c2".
+func parseCells(s string, g *Geometry) ([]int, error) {
+	var cells []int
+	for _, name := range strings.Split(s, "+") {
+		pos, err := g.ParseCellName(name)
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, pos)
+	}
+	return cells, nil
+}
+
+// formatCells encodes cells as names joined by '+', the inverse of parseCells.
+func formatCells(cells []int, g *Geometry) string {
+	names := make([]string, len(cells))
+	for i, pos := range cells {
+		names[i] = g.CellName(pos)
+	}
+	return strings.Join(names, "+")
+}
+
+// options returns the digits pos can hold: its placed digit, or its candidates if it is empty.
+func (b *Board) options(pos int) uint {
+	if val := b.cells[pos]; val != EmptyCell {
+		return 1 << (val - 1)
+	}
+	return b.unitCandidates(pos)
+}
+
+// sumRange returns the smallest and largest totals of the digits cells can hold,
+// leaving out skip. ok is false if some cell can hold no digit.
+// Digits are not taken to be distinct, so the range may be wider than the truth but never narrower.
+func (b *Board) sumRange(cells []int, skip int) (lo, hi int, ok bool) {
+	for _, pos := range cells {
+		if pos == skip {
+			continue
+		}
+		opts := b.options(pos)
+		if opts == 0 {
+			return 0, 0, false
+		}
+		lo += bits.TrailingZeros(opts) + 1
+		hi += bits.Len(opts)
+	}
+	return lo, hi, true
+}
+
+// digitsBetween returns the mask of the digits from lo to hi inclusive, clipped to 1-size.
+func digitsBetween(lo, hi, size int) uint {
+	lo, hi = max(lo, 1), min(hi, size)
+	if lo > hi {
+		return 0
+	}
+	return (1<<hi - 1) &^ (1<<(lo-1) - 1)
+}
+
+// checkLine verifies that cells are on the grid, distinct, and form a line
+// of cells each touching the last orthogonally or diagonally, as for arrows and thermometers.
+func checkLine(kind string, cells []int, g *Geometry) error {
+	for i, pos := range cells {
+		if pos < 0 || pos >= g.cells {
+			return fmt.Errorf("%w: %s: position %d out of bounds", ErrInvalidConstraint, kind, pos)
+		}
+		if slices.Contains(cells[:i], pos) {
+			return fmt.Errorf("%w: %s repeats %s", ErrInvalidConstraint, kind, g.CellName(pos))
+		}
+		if i > 0 && !g.touches(cells[i-1], pos) {
+			return fmt.Errorf("%w: %s: %s does not touch %s", ErrInvalidConstraint, kind, g.CellName(pos), g.CellName(cells[i-1]))
+		}
+	}
+	return nil
+}
+
+// touches reports whether two distinct cells are orthogonally or diagonally adjacent.
+func (g *Geometry) touches(a, b int) bool {
+	dr, dc := g.rowOf[a]-g.rowOf[b], g.colOf[a]-g.colOf[b]
+	return a != b && dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1
+}
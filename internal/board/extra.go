@@ -0,0 +1,136 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownExtraUnits = errors.New("unknown extra units")
+
+// ExtraUnits is a set of units added to the rows, columns and boxes of a grid,
+// each of which must also hold every digit once.
+type ExtraUnits uint
+
+const (
+	Diagonals ExtraUnits = 1 << iota // Both main diagonals, as in X-Sudoku
+	Windows                          // Boxes set one cell in from the edges, as in Windoku
+	CentreDot                        // The centre cells of every box
+)
+
+// extraUnitNames holds the name of each extra unit flag, in bit order.
+var extraUnitNames = [...]string{"x", "windoku", "centre-dot"}
+
+// String returns the names of the extra units joined by commas, or "none".
+func (e ExtraUnits) String() string {
+	var names []string
+	for i, name := range extraUnitNames {
+		if e&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseExtraUnits converts comma separated names such as "x,windoku" into ExtraUnits.
+// Names are matched case-insensitively; "" and "none" select no extra units.
+func ParseExtraUnits(s string) (ExtraUnits, error) {
+	var e ExtraUnits
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "none") {
+			continue
+		}
+		flag, ok := parseExtraUnit(name)
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrUnknownExtraUnits, name)
+		}
+		e |= flag
+	}
+	return e, nil
+}
+
+// parseExtraUnit looks up a single extra unit name.
+func parseExtraUnit(name string) (ExtraUnits, bool) {
+	for i, n := range extraUnitNames {
+		if strings.EqualFold(name, n) {
+			return 1 << i, true
+		}
+	}
+	return 0, false
+}
+
+// WithExtraUnits returns a geometry like g with the given extra units added to its own.
+// Windows and centre dots follow the box shape, so jigsaw grids only take diagonals,
+// and centre dots need boxes with an odd number of rows and columns.
+func (g *Geometry) WithExtraUnits(e ExtraUnits) (*Geometry, error) {
+	e |= g.extras
+	if e >= 1<<len(extraUnitNames) {
+		return nil, fmt.Errorf("%w: %#x", ErrUnknownExtraUnits, uint(e))
+	}
+	if g.Jigsaw() && e&^Diagonals != 0 {
+		return nil, fmt.Errorf("%w: jigsaw grids cannot have %s", ErrInvalidBoxShape, e&^Diagonals)
+	}
+	if e&CentreDot != 0 && (g.boxRows%2 == 0 || g.boxCols%2 == 0) {
+		return nil, fmt.Errorf("%w: centre dots need boxes of odd size, got %dx%d", ErrInvalidBoxShape, g.boxRows, g.boxCols)
+	}
+	if e == g.extras {
+		return g, nil
+	}
+
+	extended := *g
+	extended.extras = e
+	extended.initUnits()
+	return &extended, nil
+}
+
+// ExtraUnits returns the extra units of the geometry.
+func (g *Geometry) ExtraUnits() ExtraUnits {
+	return g.extras
+}
+
+// extraUnitCells returns the cells of each extra unit, grouped by unit type.
+func (g *Geometry) extraUnitCells() map[UnitType][][]int {
+	n := g.size
+	cells := make(map[UnitType][][]int)
+
+	if g.extras&Diagonals != 0 {
+		main, anti := make([]int, n), make([]int, n)
+		for i := 0; i < n; i++ {
+			main[i] = g.MakePos(i, i)
+			anti[i] = g.MakePos(i, n-1-i)
+		}
+		cells[DiagonalUnit] = [][]int{main, anti}
+	}
+
+	if g.extras&Windows != 0 {
+		// Windows start one cell in and leave a one cell gap between them
+		for top := 1; top+g.boxRows < n; top += g.boxRows + 1 {
+			for left := 1; left+g.boxCols < n; left += g.boxCols + 1 {
+				var window []int
+				for pos := 0; pos < g.cells; pos++ {
+					row, col := g.rowOf[pos], g.colOf[pos]
+					if row >= top && row < top+g.boxRows && col >= left && col < left+g.boxCols {
+						window = append(window, pos)
+					}
+				}
+				cells[WindowUnit] = append(cells[WindowUnit], window)
+			}
+		}
+	}
+
+	if g.extras&CentreDot != 0 {
+		var dots []int
+		for pos := 0; pos < g.cells; pos++ {
+			if g.rowOf[pos]%g.boxRows == g.boxRows/2 && g.colOf[pos]%g.boxCols == g.boxCols/2 {
+				dots = append(dots, pos)
+			}
+		}
+		cells[CentreDotUnit] = [][]int{dots}
+	}
+
+	return cells
+}
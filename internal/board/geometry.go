@@ -0,0 +1,251 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxSize is the largest supported grid, limited by the symbol alphabet.
+const MaxSize = len(symbols)
+
+// symbols are the characters used for digits 1 to MaxSize in compact puzzle strings:
+// '1'-'9', then letters from 'A', so 16x16 grids use 1-9 and A-G.
+const symbols = "123456789ABCDEFGHIJKLMNOP"
+
+var (
+	ErrUnsupportedSize = errors.New("unsupported grid size")
+	ErrInvalidBoxShape = errors.New("invalid box shape")
+)
+
+// Geometry describes the shape of a grid: its size, the shape of its boxes,
+// and the units and peers derived from them. Geometries are immutable
+// and shared between boards.
+type Geometry struct {
+	size    int // Digits per unit, and cells per row, column and box
+	boxRows int
+	boxCols int
+	cells   int
+
+	// Precomputed lookup tables for position mapping
+	rowOf []int
+	colOf []int
+	boxOf []int
+
+	extras    ExtraUnits
+	rules     Rules
+	units     []Unit
+	unitsOf   [][]int
+	movePeers [][]int
+	peers     [][]int
+}
+
+// Standard is the classic 9x9 geometry with 3x3 boxes.
+var Standard = mustGeometry(3, 3)
+
+// defaultGeometries holds the geometry of each supported size, keyed by size.
+// Rectangular boxes are wider than they are tall.
+var defaultGeometries = map[int]*Geometry{
+	4:  mustGeometry(2, 2),
+	6:  mustGeometry(2, 3),
+	9:  Standard,
+	12: mustGeometry(3, 4),
+	16: mustGeometry(4, 4),
+	25: mustGeometry(5, 5),
+}
+
+// Sizes returns the supported grid sizes in increasing order.
+func Sizes() []int {
+	return []int{4, 6, 9, 12, 16, 25}
+}
+
+// GeometryFor returns the geometry of a supported grid size:
+// 4x4, 6x6 with 2x3 boxes, 9x9, 12x12 with 3x4 boxes, 16x16 or 25x25.
+func GeometryFor(size int) (*Geometry, error) {
+	g, ok := defaultGeometries[size]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d, want one of %v", ErrUnsupportedSize, size, Sizes())
+	}
+	return g, nil
+}
+
+// NewGeometry builds the geometry of a grid whose boxes have the given number of rows and columns.
+// The grid is boxRows*boxCols cells on a side, and at most MaxSize.
+func NewGeometry(boxRows, boxCols int) (*Geometry, error) {
+	size := boxRows * boxCols
+	if boxRows < 2 || boxCols < 2 || size > MaxSize {
+		return nil, fmt.Errorf("%w: %dx%d", ErrInvalidBoxShape, boxRows, boxCols)
+	}
+
+	g := &Geometry{
+		size:    size,
+		boxRows: boxRows,
+		boxCols: boxCols,
+		cells:   size * size,
+	}
+	g.rowOf = make([]int, g.cells)
+	g.colOf = make([]int, g.cells)
+	g.boxOf = make([]int, g.cells)
+	for pos := 0; pos < g.cells; pos++ {
+		row, col := pos/size, pos%size
+		g.rowOf[pos] = row
+		g.colOf[pos] = col
+		g.boxOf[pos] = (row/boxRows)*(size/boxCols) + col/boxCols
+	}
+	g.initUnits()
+
+	return g, nil
+}
+
+// mustGeometry is like NewGeometry but panics on an invalid box shape.
+func mustGeometry(boxRows, boxCols int) *Geometry {
+	g, err := NewGeometry(boxRows, boxCols)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Size returns the number of digits, which is also the number of cells in a row, column or box.
+func (g *Geometry) Size() int {
+	return g.size
+}
+
+// BoxRows returns the number of rows in a box, or 0 if the boxes are jigsaw regions.
+func (g *Geometry) BoxRows() int {
+	return g.boxRows
+}
+
+// BoxCols returns the number of columns in a box, or 0 if the boxes are jigsaw regions.
+func (g *Geometry) BoxCols() int {
+	return g.boxCols
+}
+
+// CellCount returns the number of cells in the grid.
+func (g *Geometry) CellCount() int {
+	return g.cells
+}
+
+// AllDigits returns the bitmask with a bit set for every digit of the grid.
+func (g *Geometry) AllDigits() uint {
+	return 1<<g.size - 1
+}
+
+// MakePos transforms a row and column into a linear position.
+// Returns InvalidCell if row and/or col are invalid.
+func (g *Geometry) MakePos(row, col int) int {
+	if row < 0 || row >= g.size || col < 0 || col >= g.size {
+		return InvalidCell
+	}
+	return g.size*row + col
+}
+
+// String describes the geometry, e.g. "9x9", "6x6 (2x3 boxes)", "9x9 (jigsaw)", "9x9 (x,windoku)"
+// or "9x9 (anti-knight)".
+func (g *Geometry) String() string {
+	var details []string
+	if g.Jigsaw() {
+		details = append(details, "jigsaw")
+	} else if g.boxRows != g.boxCols {
+		details = append(details, fmt.Sprintf("%dx%d boxes", g.boxRows, g.boxCols))
+	}
+	if g.extras != 0 {
+		details = append(details, g.extras.String())
+	}
+	if g.rules != 0 {
+		details = append(details, g.rules.String())
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("%dx%d", g.size, g.size)
+	}
+	return fmt.Sprintf("%dx%d (%s)", g.size, g.size, strings.Join(details, ", "))
+}
+
+// Draw lays out the grid as text with lines around the boxes, writing the text of each cell
+// from cell. Cells must be one character wide on screen, though they may carry escape codes.
+// Jigsaw regions get walls wherever neighbouring cells belong to different regions.
+func (g *Geometry) Draw(cell func(pos int) string) string {
+	if g.Jigsaw() {
+		return g.drawJigsaw(cell)
+	}
+
+	var sb strings.Builder
+	line := "+" + strings.Repeat(strings.Repeat("-", 2*g.boxCols+1)+"+", g.size/g.boxCols) + "\n"
+	sb.WriteString(line)
+
+	for row := 0; row < g.size; row++ {
+		sb.WriteString("| ")
+		for col := 0; col < g.size; col++ {
+			sb.WriteString(cell(g.MakePos(row, col)))
+			sb.WriteByte(' ')
+
+			if (col+1)%g.boxCols == 0 {
+				sb.WriteString("| ")
+			}
+		}
+		sb.WriteString("\n")
+
+		if (row+1)%g.boxRows == 0 {
+			sb.WriteString(line)
+		}
+	}
+
+	return sb.String()
+}
+
+// drawJigsaw lays out a jigsaw grid, giving every cell three columns and
+// drawing walls only between regions.
+func (g *Geometry) drawJigsaw(cell func(pos int) string) string {
+	// boxAt returns the region at row, col, or -1 off the grid
+	boxAt := func(row, col int) int {
+		if pos := g.MakePos(row, col); pos != InvalidCell {
+			return g.boxOf[pos]
+		}
+		return -1
+	}
+	// Walls above and to the left of row, col
+	wallAbove := func(row, col int) bool {
+		return boxAt(row-1, col) != boxAt(row, col)
+	}
+	wallLeft := func(row, col int) bool {
+		return boxAt(row, col-1) != boxAt(row, col)
+	}
+
+	var sb strings.Builder
+	for row := 0; row <= g.size; row++ {
+		for col := 0; col <= g.size; col++ {
+			if wallAbove(row, col-1) || wallAbove(row, col) || wallLeft(row-1, col) || wallLeft(row, col) {
+				sb.WriteByte('+')
+			} else {
+				sb.WriteByte(' ')
+			}
+			if col < g.size {
+				if wallAbove(row, col) {
+					sb.WriteString("---")
+				} else {
+					sb.WriteString("   ")
+				}
+			}
+		}
+		sb.WriteString("\n")
+		if row == g.size {
+			break
+		}
+
+		for col := 0; col <= g.size; col++ {
+			if wallLeft(row, col) {
+				sb.WriteByte('|')
+			} else {
+				sb.WriteByte(' ')
+			}
+			if col < g.size {
+				sb.WriteByte(' ')
+				sb.WriteString(cell(g.MakePos(row, col)))
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,125 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidRegions = errors.New("invalid region map")
+
+// regionsKind names the region map of a jigsaw grid in puzzle strings.
+const regionsKind = "regions"
+
+// NewJigsawGeometry builds the geometry of a jigsaw grid, whose boxes are irregular regions.
+// regions holds the region of each cell, numbered from 0, and is the square of a supported size:
+// 81 entries forming nine regions of nine orthogonally connected cells for a 9x9 grid.
+func NewJigsawGeometry(regions []int) (*Geometry, error) {
+	size := 0
+	for _, n := range Sizes() {
+		if len(regions) == n*n {
+			size = n
+		}
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: %d cells, want the square of one of %v", ErrInvalidRegions, len(regions), Sizes())
+	}
+
+	count := make([]int, size)
+	for pos, r := range regions {
+		if r < 0 || r >= size {
+			return nil, fmt.Errorf("%w: region %d at position %d, want 0-%d", ErrInvalidRegions, r, pos, size-1)
+		}
+		count[r]++
+	}
+	for r, n := range count {
+		if n != size {
+			return nil, fmt.Errorf("%w: region %d has %d cells, want %d", ErrInvalidRegions, r+1, n, size)
+		}
+	}
+
+	g := &Geometry{
+		size:  size,
+		cells: size * size,
+		rowOf: make([]int, size*size),
+		colOf: make([]int, size*size),
+		boxOf: make([]int, size*size),
+	}
+	for pos := 0; pos < g.cells; pos++ {
+		g.rowOf[pos] = pos / size
+		g.colOf[pos] = pos % size
+		g.boxOf[pos] = regions[pos]
+	}
+	for r := 0; r < size; r++ {
+		if !g.Connected(regions, r) {
+			return nil, fmt.Errorf("%w: region %d is not connected", ErrInvalidRegions, r+1)
+		}
+	}
+	g.initUnits()
+
+	return g, nil
+}
+
+// Jigsaw reports whether the boxes of the grid are irregular regions.
+func (g *Geometry) Jigsaw() bool {
+	return g.boxRows == 0
+}
+
+// Connected reports whether the cells of region r form one orthogonally connected region,
+// given the region of each cell of the grid. Returns false if the region has no cells.
+func (g *Geometry) Connected(regions []int, r int) bool {
+	start, total := -1, 0
+	for pos, region := range regions {
+		if region == r {
+			if start < 0 {
+				start = pos
+			}
+			total++
+		}
+	}
+	if start < 0 {
+		return false
+	}
+
+	seen := make([]bool, len(regions))
+	seen[start] = true
+	stack := []int{start}
+	reached := 1
+	for len(stack) > 0 {
+		pos := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, n := range g.Neighbors(pos) {
+			if regions[n] == r && !seen[n] {
+				seen[n] = true
+				reached++
+				stack = append(stack, n)
+			}
+		}
+	}
+	return reached == total
+}
+
+// parseRegions decodes the spec of a region map clause: one symbol per cell naming its region from '1'.
+func parseRegions(spec string) (*Geometry, error) {
+	regions := make([]int, len(spec))
+	for pos := range spec {
+		i := strings.IndexByte(symbols, byte(unicode.ToUpper(rune(spec[pos]))))
+		if i < 0 {
+			return nil, fmt.Errorf("%w: invalid character %q at position %d", ErrInvalidRegions, spec[pos], pos)
+		}
+		regions[pos] = i
+	}
+	return NewJigsawGeometry(regions)
+}
+
+// regionsClause encodes the region map of a jigsaw grid as a puzzle string clause.
+func (g *Geometry) regionsClause() string {
+	var sb strings.Builder
+	sb.WriteString(regionsKind)
+	sb.WriteByte(':')
+	for _, box := range g.boxOf {
+		sb.WriteByte(symbols[box])
+	}
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package board
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// littleKillerKind names little killer clues in puzzle strings.
+const littleKillerKind = "little-killer"
+
+// LittleKiller is a clue outside the grid giving the sum of the digits along a diagonal,
+// which may repeat where no unit forbids it.
+type LittleKiller struct {
+	geo   *Geometry
+	sum   int
+	cells []int
+}
+
+// NewLittleKiller creates a little killer clue over the given cells,
+// which must run along a diagonal from one edge of the grid to another.
+// Returns an error if they do not, or no digits can make the sum.
+func NewLittleKiller(g *Geometry, sum int, cells []int) (*LittleKiller, error) {
+	lk := &LittleKiller{geo: g, sum: sum, cells: slices.Clone(cells)}
+	for _, pos := range cells {
+		if pos < 0 || pos >= g.cells {
+			return nil, fmt.Errorf("%w: %s: position %d out of bounds", ErrInvalidConstraint, littleKillerKind, pos)
+		}
+	}
+	if len(cells) < 2 {
+		return nil, fmt.Errorf("%w: %s has %d cells, want at least 2", ErrInvalidConstraint, lk, len(cells))
+	}
+
+	// Every step must match the first, and the steps before and after must leave the grid
+	dr, dc := g.rowOf[cells[1]]-g.rowOf[cells[0]], g.colOf[cells[1]]-g.colOf[cells[0]]
+	if dr != 1 && dr != -1 || dc != 1 && dc != -1 {
+		return nil, fmt.Errorf("%w: %s is not a diagonal", ErrInvalidConstraint, lk)
+	}
+	for i := 1; i < len(cells); i++ {
+		if g.rowOf[cells[i]]-g.rowOf[cells[i-1]] != dr || g.colOf[cells[i]]-g.colOf[cells[i-1]] != dc {
+			return nil, fmt.Errorf("%w: %s is not a diagonal", ErrInvalidConstraint, lk)
+		}
+	}
+	first, last := cells[0], cells[len(cells)-1]
+	if g.MakePos(g.rowOf[first]-dr, g.colOf[first]-dc) != InvalidCell || g.MakePos(g.rowOf[last]+dr, g.colOf[last]+dc) != InvalidCell {
+		return nil, fmt.Errorf("%w: %s does not run from edge to edge", ErrInvalidConstraint, lk)
+	}
+
+	if sum < len(cells) || sum > len(cells)*g.size {
+		return nil, fmt.Errorf("%w: %s: %d digits cannot add up to %d", ErrInvalidConstraint, lk, len(cells), sum)
+	}
+	return lk, nil
+}
+
+// parseLittleKiller decodes the spec of a little killer clause, e.g. "r1c2+r2c3+r3c4=12".
+func parseLittleKiller(spec string, g *Geometry) (*LittleKiller, error) {
+	names, sum, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: little killer %q has no sum", ErrInvalidConstraint, spec)
+	}
+	total, err := strconv.Atoi(sum)
+	if err != nil {
+		return nil, fmt.Errorf("%w: little killer %q has an invalid sum", ErrInvalidConstraint, spec)
+	}
+	cells, err := parseCells(names, g)
+	if err != nil {
+		return nil, err
+	}
+	return NewLittleKiller(g, total, cells)
+}
+
+// Sum returns the total of the digits along the diagonal.
+func (lk *LittleKiller) Sum() int {
+	return lk.sum
+}
+
+// Cells returns the cells of the diagonal.
+func (lk *LittleKiller) Cells() []int {
+	return lk.cells
+}
+
+// Check reports whether the placed digits leave a total the empty cells can make up.
+func (lk *LittleKiller) Check(b *Board) bool {
+	rem, empty := lk.sum, 0
+	for _, pos := range lk.cells {
+		if val := b.cells[pos]; val != EmptyCell {
+			rem -= val
+		} else {
+			empty++
+		}
+	}
+	return empty <= rem && rem <= empty*lk.geo.size
+}
+
+// Candidates keeps the digits of pos that leave a total within the range of the other cells.
+func (lk *LittleKiller) Candidates(b *Board, pos int, mask uint) uint {
+	lo, hi, ok := b.sumRange(lk.cells, pos)
+	if !ok {
+		return 0
+	}
+	return mask & digitsBetween(lk.sum-hi, lk.sum-lo, lk.geo.size)
+}
+
+// String encodes the clue as a puzzle string clause, e.g. "little-killer:r1c2+r2c3+r3c4=12".
+func (lk *LittleKiller) String() string {
+	return fmt.Sprintf("%s:%s=%d", littleKillerKind, formatCells(lk.cells, lk.geo), lk.sum)
+}
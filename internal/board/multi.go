@@ -0,0 +1,470 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidLayout = errors.New("invalid multi-grid layout")
+
+// Layout places several grids of one geometry on a shared canvas, such as the five
+// overlapping grids of a Samurai. Grids start on box boundaries, so any two of them
+// overlap in whole boxes, and the cells they overlap in hold the same digit in both.
+// Canvas cells are numbered row by row like the cells of a grid, gaps included.
+type Layout struct {
+	name    string
+	geo     *Geometry
+	origins [][2]int // Canvas row and column of the top left cell of each grid
+	rows    int
+	cols    int
+
+	cells     []int        // Canvas cells covered by a grid, in increasing order
+	gridCells [][]GridCell // Grid cells at each canvas cell, in grid order, nil for gaps
+}
+
+// GridCell is a cell of one grid of a layout.
+type GridCell struct {
+	Grid int // Index of the grid in the layout
+	Pos  int // Position of the cell in the grid
+}
+
+// Standard multi-grid layouts of 9x9 grids
+var (
+	// Twodoku overlaps two grids in a corner box.
+	Twodoku = mustLayout("twodoku", Standard, [][2]int{{0, 0}, {6, 6}})
+	// Butterfly overlaps four grids in a 12x12 square, each sharing six boxes with two others.
+	Butterfly = mustLayout("butterfly", Standard, [][2]int{{0, 0}, {0, 3}, {3, 0}, {3, 3}})
+	// Samurai overlaps a central grid with a grid on each of its corner boxes.
+	Samurai = mustLayout("samurai", Standard, [][2]int{{0, 0}, {0, 12}, {6, 6}, {12, 0}, {12, 12}})
+)
+
+// Layouts returns the standard layouts.
+func Layouts() []*Layout {
+	return []*Layout{Twodoku, Butterfly, Samurai}
+}
+
+// LayoutFor looks up a standard layout by name, case-insensitively.
+func LayoutFor(name string) (*Layout, error) {
+	for _, l := range Layouts() {
+		if strings.EqualFold(name, l.name) {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown layout %q", ErrInvalidLayout, name)
+}
+
+// NewLayout creates a layout of grids with the given geometry, one at each origin,
+// which gives the canvas row and column of the grid's top left cell.
+// Returns an error if the geometry is a jigsaw or has rules, if an origin is negative
+// or off a box boundary, if two grids coincide, or if the grids do not all connect through shared boxes.
+func NewLayout(name string, g *Geometry, origins [][2]int) (*Layout, error) {
+	if g.Jigsaw() {
+		return nil, fmt.Errorf("%w: grids cannot share jigsaw regions", ErrInvalidLayout)
+	}
+	if g.rules != 0 {
+		return nil, fmt.Errorf("%w: grids cannot have %s rules", ErrInvalidLayout, g.rules)
+	}
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("%w: no grids", ErrInvalidLayout)
+	}
+
+	l := &Layout{name: name, geo: g, origins: slices.Clone(origins)}
+	for i, o := range origins {
+		if o[0] < 0 || o[1] < 0 || o[0]%g.boxRows != 0 || o[1]%g.boxCols != 0 {
+			return nil, fmt.Errorf("%w: grid %d starts at row %d, column %d, off the %dx%d box boundaries",
+				ErrInvalidLayout, i+1, o[0]+1, o[1]+1, g.boxRows, g.boxCols)
+		}
+		if slices.Index(origins, o) != i {
+			return nil, fmt.Errorf("%w: grids %d and %d coincide", ErrInvalidLayout, slices.Index(origins, o)+1, i+1)
+		}
+		l.rows = max(l.rows, o[0]+g.size)
+		l.cols = max(l.cols, o[1]+g.size)
+	}
+
+	l.gridCells = make([][]GridCell, l.rows*l.cols)
+	for i, o := range origins {
+		for pos := 0; pos < g.cells; pos++ {
+			cell := (o[0]+g.rowOf[pos])*l.cols + o[1] + g.colOf[pos]
+			l.gridCells[cell] = append(l.gridCells[cell], GridCell{Grid: i, Pos: pos})
+		}
+	}
+	for cell, gcs := range l.gridCells {
+		if gcs != nil {
+			l.cells = append(l.cells, cell)
+		}
+	}
+
+	if !l.connected() {
+		return nil, fmt.Errorf("%w: grids do not all overlap", ErrInvalidLayout)
+	}
+	return l, nil
+}
+
+// mustLayout creates a layout known to be valid, panicking otherwise.
+func mustLayout(name string, g *Geometry, origins [][2]int) *Layout {
+	l, err := NewLayout(name, g, origins)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// connected reports whether every grid can be reached from the first through shared cells.
+func (l *Layout) connected() bool {
+	reached := make([]bool, len(l.origins))
+	reached[0] = true
+	queue := []int{0}
+	for len(queue) > 0 {
+		grid := queue[0]
+		queue = queue[1:]
+		for pos := 0; pos < l.geo.cells; pos++ {
+			for _, gc := range l.gridCells[l.CellOf(grid, pos)] {
+				if !reached[gc.Grid] {
+					reached[gc.Grid] = true
+					queue = append(queue, gc.Grid)
+				}
+			}
+		}
+	}
+	return !slices.Contains(reached, false)
+}
+
+// Name returns the name of the layout.
+func (l *Layout) Name() string {
+	return l.name
+}
+
+// Geometry returns the geometry shared by the grids of the layout.
+func (l *Layout) Geometry() *Geometry {
+	return l.geo
+}
+
+// GridCount returns the number of grids in the layout.
+func (l *Layout) GridCount() int {
+	return len(l.origins)
+}
+
+// Rows returns the number of rows of the canvas.
+func (l *Layout) Rows() int {
+	return l.rows
+}
+
+// Cols returns the number of columns of the canvas.
+func (l *Layout) Cols() int {
+	return l.cols
+}
+
+// Cells returns the canvas cells covered by a grid, in increasing order.
+// The returned slice is shared and must not be modified.
+func (l *Layout) Cells() []int {
+	return l.cells
+}
+
+// CellCount returns the number of canvas cells covered by a grid.
+func (l *Layout) CellCount() int {
+	return len(l.cells)
+}
+
+// GridCells returns the grid cells at a canvas cell, in grid order,
+// or nil for gaps and cells off the canvas.
+// The returned slice is shared and must not be modified.
+func (l *Layout) GridCells(cell int) []GridCell {
+	if cell < 0 || cell >= len(l.gridCells) {
+		return nil
+	}
+	return l.gridCells[cell]
+}
+
+// CellOf returns the canvas cell of a cell of a grid.
+func (l *Layout) CellOf(grid, pos int) int {
+	o := l.origins[grid]
+	return (o[0]+l.geo.rowOf[pos])*l.cols + o[1] + l.geo.colOf[pos]
+}
+
+// CellName returns the canvas name of a cell, e.g. "r13c7".
+func (l *Layout) CellName(cell int) string {
+	return fmt.Sprintf("r%dc%d", cell/l.cols+1, cell%l.cols+1)
+}
+
+// String describes the layout, e.g. "samurai (5 9x9 grids)".
+func (l *Layout) String() string {
+	return fmt.Sprintf("%s (%d %s grids)", l.name, len(l.origins), l.geo)
+}
+
+// Multi is a puzzle of several grids laid out on a shared canvas.
+// Each grid is a Board of its own, and a digit placed in a shared cell goes into every grid
+// covering it, so the grids always agree.
+type Multi struct {
+	layout *Layout
+	grids  []*Board
+}
+
+// NewMulti creates an empty puzzle of the given layout.
+func NewMulti(l *Layout) *Multi {
+	m := &Multi{layout: l, grids: make([]*Board, len(l.origins))}
+	for i := range m.grids {
+		m.grids[i] = NewWithGeometry(l.geo)
+	}
+	return m
+}
+
+// NewMultiFromString creates a multi-grid puzzle from the form written by String:
+// the name of a standard layout and a colon, followed by the covered canvas cells row by row
+// in the compact or token format read by NewFromString, e.g. "twodoku:53..7....6..195...".
+func NewMultiFromString(s string) (*Multi, error) {
+	name, grid, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: missing layout name before ':'", ErrInvalidLayout)
+	}
+	l, err := LayoutFor(strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := tokenize(strings.TrimSpace(grid))
+	if len(tokens) != len(l.cells) {
+		return nil, fmt.Errorf("%s puzzle must have exactly %d cells, got %d", l.name, len(l.cells), len(tokens))
+	}
+	m := NewMulti(l)
+	for i, tok := range tokens {
+		val, ok := parseDigit(tok)
+		if !ok || val > l.geo.size {
+			return nil, fmt.Errorf("invalid cell %q at %s", tok, l.CellName(l.cells[i]))
+		}
+		if val == EmptyCell {
+			continue
+		}
+		if err := m.Set(l.cells[i], val); err != nil {
+			return nil, fmt.Errorf("invalid board at %s: %w", l.CellName(l.cells[i]), err)
+		}
+	}
+	return m, nil
+}
+
+// Clone creates an independent copy of the puzzle.
+func (m *Multi) Clone() *Multi {
+	if m == nil {
+		return nil
+	}
+	clone := &Multi{layout: m.layout, grids: make([]*Board, len(m.grids))}
+	for i, b := range m.grids {
+		clone.grids[i] = b.Clone()
+	}
+	return clone
+}
+
+// Layout returns the layout of the puzzle.
+func (m *Multi) Layout() *Layout {
+	return m.layout
+}
+
+// Grid returns one grid of the puzzle.
+// The board is shared and must not be modified, or the grids may stop agreeing.
+func (m *Multi) Grid(i int) *Board {
+	return m.grids[i]
+}
+
+// Get returns the value at a canvas cell.
+// Returns InvalidCell for gaps and cells off the canvas.
+func (m *Multi) Get(cell int) int {
+	gcs := m.layout.GridCells(cell)
+	if gcs == nil {
+		return InvalidCell
+	}
+	return m.grids[gcs[0].Grid].cells[gcs[0].Pos]
+}
+
+// Set places a digit at a canvas cell of every grid covering it.
+// Returns an error, leaving the puzzle as it was, if the cell is not covered
+// or the digit breaks the rules of any of those grids.
+func (m *Multi) Set(cell, val int) error {
+	gcs, err := m.gridCells(cell)
+	if err != nil {
+		return err
+	}
+
+	prev := m.Get(cell)
+	for i, gc := range gcs {
+		if err := m.grids[gc.Grid].Set(gc.Pos, val); err != nil {
+			// A failed Set may already have cleared the cell, so restore it in that grid too
+			for _, done := range gcs[:i+1] {
+				b := m.grids[done.Grid]
+				b.Clear(done.Pos)
+				if prev != EmptyCell {
+					b.SetForce(done.Pos, prev)
+				}
+			}
+			return fmt.Errorf("grid %d: %w", gc.Grid+1, err)
+		}
+	}
+	return nil
+}
+
+// SetForce places a digit at a covered canvas cell of every grid without validation checks.
+// Use only when certain the move is valid.
+func (m *Multi) SetForce(cell, val int) {
+	for _, gc := range m.layout.gridCells[cell] {
+		m.grids[gc.Grid].SetForce(gc.Pos, val)
+	}
+}
+
+// Clear empties a canvas cell in every grid covering it.
+// Returns an error if the cell is not covered.
+func (m *Multi) Clear(cell int) error {
+	gcs, err := m.gridCells(cell)
+	if err != nil {
+		return err
+	}
+	for _, gc := range gcs {
+		m.grids[gc.Grid].Clear(gc.Pos)
+	}
+	return nil
+}
+
+// GetCandidatesMask returns the digits a canvas cell may hold in every grid covering it,
+// as a bitmask like Board.GetCandidatesMask, which does not tell filled cells apart.
+// Returns 0 for gaps.
+func (m *Multi) GetCandidatesMask(cell int) uint {
+	gcs := m.layout.GridCells(cell)
+	if gcs == nil {
+		return 0
+	}
+	mask := m.layout.geo.AllDigits()
+	for _, gc := range gcs {
+		mask &= m.grids[gc.Grid].GetCandidatesMask(gc.Pos)
+	}
+	return mask
+}
+
+// gridCells returns the grid cells at a canvas cell, or an error if it is not covered.
+func (m *Multi) gridCells(cell int) ([]GridCell, error) {
+	gcs := m.layout.GridCells(cell)
+	if gcs == nil {
+		return nil, fmt.Errorf("%w: canvas cell %d is not in any grid of %s", ErrInvalidPosition, cell, m.layout.name)
+	}
+	return gcs, nil
+}
+
+// CellCount returns the number of canvas cells covered by a grid.
+func (m *Multi) CellCount() int {
+	return len(m.layout.cells)
+}
+
+// EmptyCount returns the number of empty canvas cells, counting shared cells once.
+func (m *Multi) EmptyCount() int {
+	n := 0
+	for _, cell := range m.layout.cells {
+		if m.Get(cell) == EmptyCell {
+			n++
+		}
+	}
+	return n
+}
+
+// ClueCount returns the number of filled canvas cells, counting shared cells once.
+func (m *Multi) ClueCount() int {
+	return m.CellCount() - m.EmptyCount()
+}
+
+// IsValid reports whether every grid satisfies Sudoku constraints.
+func (m *Multi) IsValid() bool {
+	for _, b := range m.grids {
+		if !b.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the puzzle in the form read by NewMultiFromString:
+// the layout name, a colon, and one character per covered canvas cell, row by row.
+func (m *Multi) String() string {
+	var sb strings.Builder
+	sb.Grow(len(m.layout.name) + 1 + len(m.layout.cells))
+	sb.WriteString(m.layout.name)
+	sb.WriteByte(':')
+	sb.WriteString(m.GridString())
+	return sb.String()
+}
+
+// GridString is like String but leaves out the layout name.
+func (m *Multi) GridString() string {
+	var sb strings.Builder
+	sb.Grow(len(m.layout.cells))
+	for _, cell := range m.layout.cells {
+		sb.WriteByte(symbol(m.Get(cell)))
+	}
+	return sb.String()
+}
+
+// Format returns a human-readable picture of the whole canvas with lines around the boxes
+// of every grid, leaving the gaps between grids blank.
+func (m *Multi) Format() string {
+	l := m.layout
+	g := l.geo
+	boxRows, boxCols := l.rows/g.boxRows, l.cols/g.boxCols
+
+	// covered reports whether the box at a box row and column of the canvas lies in a grid
+	covered := func(br, bc int) bool {
+		if br < 0 || bc < 0 || br >= boxRows || bc >= boxCols {
+			return false
+		}
+		return l.gridCells[br*g.boxRows*l.cols+bc*g.boxCols] != nil
+	}
+
+	var sb strings.Builder
+	var line strings.Builder
+	for row := 0; row <= l.rows; row++ {
+		br := row / g.boxRows
+		if row%g.boxRows == 0 {
+			line.Reset()
+			for bc := 0; bc <= boxCols; bc++ {
+				if covered(br-1, bc-1) || covered(br-1, bc) || covered(br, bc-1) || covered(br, bc) {
+					line.WriteByte('+')
+				} else {
+					line.WriteByte(' ')
+				}
+				if bc == boxCols {
+					break
+				}
+				if covered(br-1, bc) || covered(br, bc) {
+					line.WriteString(strings.Repeat("-", 2*g.boxCols+1))
+				} else {
+					line.WriteString(strings.Repeat(" ", 2*g.boxCols+1))
+				}
+			}
+			sb.WriteString(strings.TrimRight(line.String(), " "))
+			sb.WriteByte('\n')
+		}
+		if row == l.rows {
+			break
+		}
+
+		line.Reset()
+		for bc := 0; bc <= boxCols; bc++ {
+			if covered(br, bc-1) || covered(br, bc) {
+				line.WriteByte('|')
+			} else {
+				line.WriteByte(' ')
+			}
+			if bc == boxCols {
+				break
+			}
+			line.WriteByte(' ')
+			for col := bc * g.boxCols; col < (bc+1)*g.boxCols; col++ {
+				if covered(br, bc) {
+					line.WriteByte(symbol(m.Get(row*l.cols + col)))
+				} else {
+					line.WriteByte(' ')
+				}
+				line.WriteByte(' ')
+			}
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,62 @@
+package board
+
+// nonConsecutiveKind names the non-consecutive rule in puzzle strings.
+// The rule covers the whole grid, so its clause has no spec.
+const nonConsecutiveKind = "nonconsecutive"
+
+// NonConsecutive is the global rule that no two orthogonally adjacent cells hold consecutive digits.
+type NonConsecutive struct {
+	cells     []int
+	neighbors [][]int
+}
+
+// NewNonConsecutive creates the non-consecutive rule for a grid.
+func NewNonConsecutive(g *Geometry) *NonConsecutive {
+	nc := &NonConsecutive{
+		cells:     make([]int, g.cells),
+		neighbors: make([][]int, g.cells),
+	}
+	for pos := range nc.cells {
+		nc.cells[pos] = pos
+		nc.neighbors[pos] = g.Neighbors(pos)
+	}
+	return nc
+}
+
+// Cells returns every cell of the grid.
+func (nc *NonConsecutive) Cells() []int {
+	return nc.cells
+}
+
+// Check reports whether no two placed neighbours hold consecutive digits.
+func (nc *NonConsecutive) Check(b *Board) bool {
+	for pos, val := range b.cells {
+		if val == EmptyCell {
+			continue
+		}
+		for _, n := range nc.neighbors[pos] {
+			if d := b.cells[n] - val; n > pos && b.cells[n] != EmptyCell && (d == 1 || d == -1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Candidates removes from mask the digits next to those placed in the neighbours of pos.
+func (nc *NonConsecutive) Candidates(b *Board, pos int, mask uint) uint {
+	for _, n := range nc.neighbors[pos] {
+		if val := b.cells[n]; val != EmptyCell {
+			mask &^= 1 << val // val+1
+			if val > 1 {
+				mask &^= 1 << (val - 2) // val-1
+			}
+		}
+	}
+	return mask
+}
+
+// String encodes the rule as a puzzle string clause, "nonconsecutive".
+func (nc *NonConsecutive) String() string {
+	return nonConsecutiveKind
+}
@@ -0,0 +1,163 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// RelationKind is a rule tying the digits of two orthogonally adjacent cells together.
+type RelationKind int
+
+const (
+	WhiteDot    RelationKind = iota // Kropki white dot: the digits are consecutive
+	BlackDot                        // Kropki black dot: one digit is double the other
+	XSum                            // XV X: the digits add up to 10
+	VSum                            // XV V: the digits add up to 5
+	GreaterThan                     // The first digit is greater than the second
+)
+
+// relationKinds holds the puzzle string kind of each relation, in order.
+var relationKinds = [...]string{"white", "black", "x", "v", "gt"}
+
+// String returns the puzzle string kind of the relation, e.g. "white".
+func (k RelationKind) String() string {
+	if k < 0 || int(k) >= len(relationKinds) {
+		return "unknown"
+	}
+	return relationKinds[k]
+}
+
+// parseRelationKind looks up the kind of a relation clause.
+func parseRelationKind(kind string) (RelationKind, bool) {
+	i := slices.Index(relationKinds[:], strings.ToLower(kind))
+	return RelationKind(i), i >= 0
+}
+
+// holds reports whether a in the first cell and b in the second satisfy the relation.
+func (k RelationKind) holds(a, b int) bool {
+	switch k {
+	case WhiteDot:
+		return a-b == 1 || b-a == 1
+	case BlackDot:
+		return a == 2*b || b == 2*a
+	case XSum:
+		return a+b == 10
+	case VSum:
+		return a+b == 5
+	case GreaterThan:
+		return a > b
+	default:
+		return false
+	}
+}
+
+// Relation is a constraint between two orthogonally adjacent cells, such as a Kropki dot.
+type Relation struct {
+	geo   *Geometry
+	kind  RelationKind
+	cells []int
+}
+
+// NewRelation creates a relation of the given kind between cells a and b of a grid.
+// For GreaterThan, a holds the greater digit.
+// Returns an error if the cells are not orthogonally adjacent or no two digits of the grid satisfy it.
+func NewRelation(g *Geometry, kind RelationKind, a, b int) (*Relation, error) {
+	r := &Relation{geo: g, kind: kind, cells: []int{a, b}}
+	if kind < 0 || int(kind) >= len(relationKinds) {
+		return nil, fmt.Errorf("%w: unknown relation %d", ErrInvalidConstraint, int(kind))
+	}
+	for _, pos := range r.cells {
+		if pos < 0 || pos >= g.cells {
+			return nil, fmt.Errorf("%w: %s relation: position %d out of bounds", ErrInvalidConstraint, kind, pos)
+		}
+	}
+	if !slices.Contains(g.Neighbors(a), b) {
+		return nil, fmt.Errorf("%w: %s: cells are not orthogonally adjacent", ErrInvalidConstraint, r)
+	}
+	if r.partners(g.AllDigits(), 0) == 0 {
+		return nil, fmt.Errorf("%w: %s: no two digits satisfy it", ErrInvalidConstraint, r)
+	}
+	return r, nil
+}
+
+// parseRelation decodes the spec of a relation clause, e.g. "r1c1+r1c2".
+func parseRelation(kind RelationKind, spec string, g *Geometry) (*Relation, error) {
+	cells, err := parseCells(spec, g)
+	if err != nil {
+		return nil, err
+	}
+	if len(cells) != 2 {
+		return nil, fmt.Errorf("%w: %s relation %q has %d cells, want 2", ErrInvalidConstraint, kind, spec, len(cells))
+	}
+	return NewRelation(g, kind, cells[0], cells[1])
+}
+
+// Kind returns the kind of the relation.
+func (r *Relation) Kind() RelationKind {
+	return r.kind
+}
+
+// Cells returns the two cells of the relation, the greater one first for GreaterThan.
+func (r *Relation) Cells() []int {
+	return r.cells
+}
+
+// Check reports whether the placed digits satisfy the relation,
+// or leave some digit for an empty cell that would.
+func (r *Relation) Check(b *Board) bool {
+	first, second := b.cells[r.cells[0]], b.cells[r.cells[1]]
+	switch {
+	case first == EmptyCell && second == EmptyCell:
+		return true
+	case first == EmptyCell:
+		return r.partners(1<<(second-1), 1) != 0
+	case second == EmptyCell:
+		return r.partners(1<<(first-1), 0) != 0
+	default:
+		return r.kind.holds(first, second)
+	}
+}
+
+// Candidates keeps the digits of pos that the other cell can answer,
+// with its placed digit or one of its candidates.
+func (r *Relation) Candidates(b *Board, pos int, mask uint) uint {
+	side, other := 0, r.cells[1]
+	if pos == other {
+		side, other = 1, r.cells[0]
+	}
+
+	var options uint
+	if val := b.cells[other]; val != EmptyCell {
+		options = 1 << (val - 1)
+	} else {
+		options = b.unitCandidates(other)
+	}
+
+	// options are on the other side, so the digits that answer them are on ours
+	return mask & r.partners(options, 1-side)
+}
+
+// partners returns the digits that satisfy the relation on the other side from
+// some digit of mask on the given side, 0 for the first cell and 1 for the second.
+// The two cells share a row or column, so their digits differ.
+func (r *Relation) partners(mask uint, side int) uint {
+	var found uint
+	all := r.geo.AllDigits()
+	for m := mask; m != 0; m &= m - 1 {
+		val := bits.TrailingZeros(m) + 1
+		for o := all &^ (1 << (val - 1)); o != 0; o &= o - 1 {
+			other := bits.TrailingZeros(o) + 1
+			if side == 0 && r.kind.holds(val, other) || side == 1 && r.kind.holds(other, val) {
+				found |= 1 << (other - 1)
+			}
+		}
+	}
+	return found
+}
+
+// String encodes the relation as a puzzle string clause, e.g. "white:r1c1+r1c2".
+func (r *Relation) String() string {
+	return fmt.Sprintf("%s:%s", r.kind, formatCells(r.cells, r.geo))
+}
@@ -0,0 +1,132 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownRules = errors.New("unknown rules")
+
+// Rules is a set of global rules that forbid a digit from repeating a chess move apart.
+// Cells a move apart become peers without sharing a unit.
+type Rules uint
+
+const (
+	AntiKnight Rules = 1 << iota // No digit repeats a knight's move apart
+	AntiKing                     // No digit repeats a king's move apart, which adds the diagonal neighbours
+)
+
+// ruleNames holds the name of each rule flag, in bit order.
+var ruleNames = [...]string{"anti-knight", "anti-king"}
+
+// ruleMoves holds the row and column offsets of the moves of each rule, in bit order.
+var ruleMoves = [...][][2]int{
+	{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}},
+	{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}},
+}
+
+// String returns the names of the rules joined by commas, or "none".
+func (r Rules) String() string {
+	var names []string
+	for i, name := range ruleNames {
+		if r&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseRules converts comma separated names such as "anti-knight,anti-king" into Rules.
+// Names are matched case-insensitively; "" and "none" select no rules.
+func ParseRules(s string) (Rules, error) {
+	var r Rules
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "none") {
+			continue
+		}
+		flag, ok := parseRule(name)
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrUnknownRules, name)
+		}
+		r |= flag
+	}
+	return r, nil
+}
+
+// parseRule looks up a single rule name.
+func parseRule(name string) (Rules, bool) {
+	for i, n := range ruleNames {
+		if strings.EqualFold(name, n) {
+			return 1 << i, true
+		}
+	}
+	return 0, false
+}
+
+// WithRules returns a geometry like g with the given rules added to its own.
+func (g *Geometry) WithRules(r Rules) (*Geometry, error) {
+	r |= g.rules
+	if r >= 1<<len(ruleNames) {
+		return nil, fmt.Errorf("%w: %#x", ErrUnknownRules, uint(r))
+	}
+	if r == g.rules {
+		return g, nil
+	}
+
+	extended := *g
+	extended.rules = r
+	extended.initUnits()
+	return &extended, nil
+}
+
+// Rules returns the rules of the geometry.
+func (g *Geometry) Rules() Rules {
+	return g.rules
+}
+
+// MovePeers returns the cells the rules make peers of pos without sharing a unit with it.
+// The returned slice is shared and must not be modified.
+func (g *Geometry) MovePeers(pos int) []int {
+	return g.movePeers[pos]
+}
+
+// moveRule returns the rules that forbid a and b from holding the same digit.
+func (g *Geometry) moveRule(a, b int) Rules {
+	var r Rules
+	dr, dc := g.rowOf[b]-g.rowOf[a], g.colOf[b]-g.colOf[a]
+	for i, moves := range ruleMoves {
+		for _, m := range moves {
+			if g.rules&(1<<i) != 0 && m == [2]int{dr, dc} {
+				r |= 1 << i
+			}
+		}
+	}
+	return r
+}
+
+// initMovePeers builds the table of cells a move apart from each cell that share no unit with it.
+func (g *Geometry) initMovePeers() {
+	g.movePeers = make([][]int, g.cells)
+	for i, moves := range ruleMoves {
+		if g.rules&(1<<i) == 0 {
+			continue
+		}
+		for pos := 0; pos < g.cells; pos++ {
+			for _, m := range moves {
+				other := g.MakePos(g.rowOf[pos]+m[0], g.colOf[pos]+m[1])
+				if other != InvalidCell && !g.sharesUnit(pos, other) && !slices.Contains(g.movePeers[pos], other) {
+					g.movePeers[pos] = append(g.movePeers[pos], other)
+				}
+			}
+		}
+	}
+	for _, peers := range g.movePeers {
+		slices.Sort(peers)
+	}
+}
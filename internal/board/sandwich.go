@@ -0,0 +1,186 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// sandwichKind names sandwich clues in puzzle strings.
+const sandwichKind = "sandwich"
+
+// Sandwich is a clue outside a row or column giving the sum of the digits
+// between its crusts, the 1 and the largest digit.
+type Sandwich struct {
+	geo  *Geometry
+	line Unit
+	sum  int
+}
+
+// NewSandwich creates a sandwich clue for a row or column unit of a grid.
+// Returns an error for other units, or if no digits between the crusts can make the sum.
+func NewSandwich(g *Geometry, line Unit, sum int) (*Sandwich, error) {
+	if line.Type != RowUnit && line.Type != ColUnit {
+		return nil, fmt.Errorf("%w: sandwich clue on %s, want a row or column", ErrInvalidConstraint, line)
+	}
+	s := &Sandwich{geo: g, line: line, sum: sum}
+	if most := g.size*(g.size+1)/2 - 1 - g.size; sum < 0 || sum > most {
+		return nil, fmt.Errorf("%w: %s: no digits between the crusts add up to %d", ErrInvalidConstraint, s, sum)
+	}
+	return s, nil
+}
+
+// parseSandwich decodes the spec of a sandwich clause, e.g. "r3=15" or "c4=0".
+func parseSandwich(spec string, g *Geometry) (*Sandwich, error) {
+	name, sum, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: sandwich %q has no sum", ErrInvalidConstraint, spec)
+	}
+	total, err := strconv.Atoi(sum)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sandwich %q has an invalid sum", ErrInvalidConstraint, spec)
+	}
+	index, err := strconv.Atoi(strings.ToLower(name)[min(1, len(name)):])
+	if err != nil || index < 1 || index > g.size {
+		return nil, fmt.Errorf("%w: sandwich %q has an invalid row or column", ErrInvalidConstraint, spec)
+	}
+	switch name[0] {
+	case 'r', 'R':
+		return NewSandwich(g, g.units[index-1], total)
+	case 'c', 'C':
+		return NewSandwich(g, g.units[g.size+index-1], total)
+	default:
+		return nil, fmt.Errorf("%w: sandwich %q has an invalid row or column", ErrInvalidConstraint, spec)
+	}
+}
+
+// Line returns the row or column of the clue.
+func (s *Sandwich) Line() Unit {
+	return s.line
+}
+
+// Sum returns the total of the digits between the crusts.
+func (s *Sandwich) Sum() int {
+	return s.sum
+}
+
+// Cells returns the cells of the row or column.
+func (s *Sandwich) Cells() []int {
+	return s.line.Cells
+}
+
+// Check reports whether the crusts can still go somewhere that lets the digits between them make the sum.
+func (s *Sandwich) Check(b *Board) bool {
+	var vals [MaxSize]int
+	var opts [MaxSize]uint
+	var placed uint
+	for k, pos := range s.line.Cells {
+		if vals[k] = b.cells[pos]; vals[k] != EmptyCell {
+			placed |= 1 << (vals[k] - 1)
+		}
+	}
+	for k := range s.line.Cells {
+		if vals[k] != EmptyCell {
+			opts[k] = 1 << (vals[k] - 1)
+		} else {
+			opts[k] = s.geo.AllDigits() &^ placed
+		}
+	}
+	n := len(s.line.Cells)
+	_, ok := s.fits(vals[:n], opts[:n], -1)
+	return ok
+}
+
+// Candidates keeps the digits of pos that some placement of the crusts allows:
+// a crust, a digit outside them, or a digit between them leaving a sum the other cells can make.
+func (s *Sandwich) Candidates(b *Board, pos int, mask uint) uint {
+	var vals [MaxSize]int
+	var opts [MaxSize]uint
+	p := -1
+	for k, cell := range s.line.Cells {
+		if cell == pos {
+			p, opts[k] = k, mask
+			continue
+		}
+		vals[k], opts[k] = b.cells[cell], b.options(cell)
+	}
+	n := len(s.line.Cells)
+	allowed, _ := s.fits(vals[:n], opts[:n], p)
+	return mask & allowed
+}
+
+// fits tries every placement of the crusts that opts, the digits each cell of the line can hold, allow.
+// vals holds the placed digits, 0 for empty cells. ok reports whether any placement works,
+// and allowed gathers the digits each working placement leaves for the cell at index p.
+func (s *Sandwich) fits(vals []int, opts []uint, p int) (allowed uint, ok bool) {
+	one, top := uint(1), uint(1)<<(s.geo.size-1)
+	filling := s.geo.AllDigits() &^ one &^ top
+
+	var placed uint
+	for _, val := range vals {
+		if val != EmptyCell {
+			placed |= 1 << (val - 1)
+		}
+	}
+
+	for i := range opts {
+		if opts[i]&one == 0 {
+			continue
+		}
+		for j := range opts {
+			if j == i || opts[j]&top == 0 {
+				continue
+			}
+			lo, hi := min(i, j), max(i, j)
+
+			// Every other cell needs a filling digit, and those between need to make the sum
+			rem, empty, fits := s.sum, 0, true
+			for k := range opts {
+				if k == i || k == j {
+					continue
+				}
+				if opts[k]&filling == 0 {
+					fits = false
+					break
+				}
+				if k > lo && k < hi {
+					if vals[k] != EmptyCell {
+						rem -= vals[k]
+					} else {
+						empty++
+					}
+				}
+			}
+			avail := filling &^ placed
+			if !fits || !reachable(empty, rem, avail) {
+				continue
+			}
+			ok = true
+
+			switch {
+			case p == i:
+				allowed |= one
+			case p == j:
+				allowed |= top
+			case p < 0:
+				return allowed, true
+			case p < lo || p > hi || vals[p] != EmptyCell:
+				allowed |= opts[p] & filling
+			default:
+				for m := opts[p] & avail; m != 0; m &= m - 1 {
+					bit := m & -m
+					if reachable(empty-1, rem-bits.TrailingZeros(bit)-1, avail&^bit) {
+						allowed |= bit
+					}
+				}
+			}
+		}
+	}
+	return allowed, ok
+}
+
+// String encodes the clue as a puzzle string clause, e.g. "sandwich:r3=15".
+func (s *Sandwich) String() string {
+	return fmt.Sprintf("%s:%c%d=%d", sandwichKind, s.line.Type.String()[0], s.line.Index+1, s.sum)
+}
@@ -0,0 +1,94 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// thermoKind names thermometers in puzzle strings.
+const thermoKind = "thermo"
+
+// Thermometer is a line of cells whose digits strictly increase from its bulb, the first cell.
+type Thermometer struct {
+	geo   *Geometry
+	cells []int
+}
+
+// NewThermometer creates a thermometer along the given cells, starting at the bulb.
+// Returns an error if the cells do not form a line or outnumber the digits.
+func NewThermometer(g *Geometry, cells []int) (*Thermometer, error) {
+	if err := checkLine(thermoKind, cells, g); err != nil {
+		return nil, err
+	}
+	t := &Thermometer{geo: g, cells: slices.Clone(cells)}
+	if len(cells) < 2 || len(cells) > g.size {
+		return nil, fmt.Errorf("%w: %s has %d cells, want 2-%d", ErrInvalidConstraint, t, len(cells), g.size)
+	}
+	return t, nil
+}
+
+// parseThermometer decodes the spec of a thermometer clause, e.g. "r1c1+r2c1+r3c2".
+func parseThermometer(spec string, g *Geometry) (*Thermometer, error) {
+	cells, err := parseCells(spec, g)
+	if err != nil {
+		return nil, err
+	}
+	return NewThermometer(g, cells)
+}
+
+// Cells returns the cells of the thermometer, from the bulb.
+func (t *Thermometer) Cells() []int {
+	return t.cells
+}
+
+// Check reports whether the placed digits increase along the thermometer
+// with room for the digits of the empty cells between and around them.
+func (t *Thermometer) Check(b *Board) bool {
+	lastIdx, lastVal := -1, 0
+	for i, pos := range t.cells {
+		val := b.cells[pos]
+		if val == EmptyCell {
+			continue
+		}
+		if val-lastVal < i-lastIdx {
+			return false
+		}
+		lastIdx, lastVal = i, val
+	}
+	return t.geo.size+1-lastVal >= len(t.cells)-lastIdx
+}
+
+// Candidates keeps the digits of pos above the lowest the cells before it can rise to,
+// and below the highest the cells after it can fall to.
+func (t *Thermometer) Candidates(b *Board, pos int, mask uint) uint {
+	k := 0
+	for t.cells[k] != pos {
+		k++
+	}
+
+	low := 0
+	for _, cell := range t.cells[:k] {
+		above := b.options(cell) &^ (1<<low - 1)
+		if above == 0 {
+			return 0
+		}
+		low = bits.TrailingZeros(above) + 1
+	}
+
+	high := t.geo.size + 1
+	for i := len(t.cells) - 1; i > k; i-- {
+		below := b.options(t.cells[i]) & (1<<(high-1) - 1)
+		if below == 0 {
+			return 0
+		}
+		high = bits.Len(below)
+	}
+
+	return mask & digitsBetween(low+1, high-1, t.geo.size)
+}
+
+// String encodes the thermometer as a puzzle string clause, e.g. "thermo:r1c1+r2c1+r3c2".
+func (t *Thermometer) String() string {
+	return fmt.Sprintf("%s:%s", thermoKind, formatCells(t.cells, t.geo))
+}
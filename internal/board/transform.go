@@ -0,0 +1,416 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"slices"
+)
+
+var (
+	ErrInvalidTransform     = errors.New("invalid transform")
+	ErrUnsupportedTransform = errors.New("puzzle cannot be transformed")
+)
+
+// Transform rearranges a grid without changing which puzzles have which solutions:
+// it moves every cell to a new position and relabels every digit.
+// Transforms are invertible, so answers on a transformed puzzle map back to the original.
+type Transform struct {
+	geo    *Geometry        // Shape of the grids transformed, which transforms never change
+	from   []int            // Old position of the cell at each new position
+	to     []int            // New position of each old cell
+	digits [MaxSize + 1]int // New digit of each old digit, EmptyCell kept empty
+}
+
+// moveCells creates a transform of grids of g that moves each cell at row, col
+// to the row and column returned by move, keeping the digits.
+func moveCells(g *Geometry, move func(row, col int) (int, int)) *Transform {
+	t := &Transform{
+		geo:  g,
+		from: make([]int, g.cells),
+		to:   make([]int, g.cells),
+	}
+	for pos := 0; pos < g.cells; pos++ {
+		newPos := g.MakePos(move(g.rowOf[pos], g.colOf[pos]))
+		t.to[pos] = newPos
+		t.from[newPos] = pos
+	}
+	for val := range t.digits {
+		t.digits[val] = val
+	}
+	return t
+}
+
+// checkSquareBoxes checks that boxes of g keep their shape when turned on their side,
+// which puzzle strings rely on as they do not record the box shape.
+func (g *Geometry) checkSquareBoxes(what string) error {
+	if g.boxRows != g.boxCols {
+		return fmt.Errorf("%w: %s would turn the %dx%d boxes of %s on their side", ErrUnsupportedTransform, what, g.boxRows, g.boxCols, g)
+	}
+	return nil
+}
+
+// checkBands checks that g has regular boxes, whose bands and stacks the row and column orders keep together.
+func (g *Geometry) checkBands(what string) error {
+	if g.Jigsaw() {
+		return fmt.Errorf("%w: %s needs the bands and stacks of regular boxes, which %s lacks", ErrUnsupportedTransform, what, g)
+	}
+	return nil
+}
+
+// checkPermutation checks that order holds each of 0 to n-1 once.
+func checkPermutation(what string, order []int, n int) error {
+	seen := make([]bool, n)
+	for _, i := range order {
+		if i < 0 || i >= n || seen[i] {
+			return fmt.Errorf("%w: %s %v is not an order of 0-%d", ErrInvalidTransform, what, order, n-1)
+		}
+		seen[i] = true
+	}
+	if len(order) != n {
+		return fmt.Errorf("%w: %s %v is not an order of 0-%d", ErrInvalidTransform, what, order, n-1)
+	}
+	return nil
+}
+
+// inverse returns the new index of each old index of an order that lists the old indices in their new order.
+func inverse(order []int) []int {
+	inv := make([]int, len(order))
+	for i, old := range order {
+		inv[old] = i
+	}
+	return inv
+}
+
+// Rotation returns the transform that turns grids of g clockwise by the given number of quarter turns,
+// which may be negative. Returns an error for an odd number of turns of a grid with rectangular boxes.
+func (g *Geometry) Rotation(turns int) (*Transform, error) {
+	n := g.size - 1
+	turns = (turns%4 + 4) % 4
+	if turns%2 == 1 {
+		if err := g.checkSquareBoxes("a quarter turn"); err != nil {
+			return nil, err
+		}
+	}
+	switch turns {
+	case 1:
+		return moveCells(g, func(row, col int) (int, int) { return col, n - row }), nil
+	case 2:
+		return moveCells(g, func(row, col int) (int, int) { return n - row, n - col }), nil
+	case 3:
+		return moveCells(g, func(row, col int) (int, int) { return n - col, row }), nil
+	default:
+		return moveCells(g, func(row, col int) (int, int) { return row, col }), nil
+	}
+}
+
+// HorizontalReflection returns the transform that mirrors grids of g across the middle row.
+func (g *Geometry) HorizontalReflection() *Transform {
+	return moveCells(g, func(row, col int) (int, int) { return g.size - 1 - row, col })
+}
+
+// VerticalReflection returns the transform that mirrors grids of g across the middle column.
+func (g *Geometry) VerticalReflection() *Transform {
+	return moveCells(g, func(row, col int) (int, int) { return row, g.size - 1 - col })
+}
+
+// Transposition returns the transform that mirrors grids of g across the main diagonal.
+// Returns an error for grids with rectangular boxes.
+func (g *Geometry) Transposition() (*Transform, error) {
+	if err := g.checkSquareBoxes("transposing"); err != nil {
+		return nil, err
+	}
+	return moveCells(g, func(row, col int) (int, int) { return col, row }), nil
+}
+
+// Relabeling returns the transform that replaces each digit d of grids of g by digits[d-1].
+// Returns an error unless digits holds each of 1 to Size once.
+func (g *Geometry) Relabeling(digits []int) (*Transform, error) {
+	seen := make([]bool, g.size+1)
+	for _, d := range digits {
+		if d < 1 || d > g.size || seen[d] {
+			break
+		}
+		seen[d] = true
+	}
+	if len(digits) != g.size || slices.Contains(seen[1:], false) {
+		return nil, fmt.Errorf("%w: digits %v are not an order of 1-%d", ErrInvalidTransform, digits, g.size)
+	}
+
+	t := moveCells(g, func(row, col int) (int, int) { return row, col })
+	for i, d := range digits {
+		t.digits[i+1] = d
+	}
+	return t, nil
+}
+
+// RowPermutation returns the transform that reorders the rows within a band of grids of g,
+// the band counted from 0 at the top. order lists the band's old rows, counted from 0 within the band,
+// in their new order, so [2 0 1] moves its last row to the top.
+func (g *Geometry) RowPermutation(band int, order []int) (*Transform, error) {
+	if err := g.checkBands("reordering rows"); err != nil {
+		return nil, err
+	}
+	if band < 0 || band >= g.size/g.boxRows {
+		return nil, fmt.Errorf("%w: band %d must be in range [0, %d)", ErrInvalidTransform, band, g.size/g.boxRows)
+	}
+	if err := checkPermutation("row order", order, g.boxRows); err != nil {
+		return nil, err
+	}
+	newRow := inverse(order)
+	return moveCells(g, func(row, col int) (int, int) {
+		if row/g.boxRows != band {
+			return row, col
+		}
+		return band*g.boxRows + newRow[row%g.boxRows], col
+	}), nil
+}
+
+// BandPermutation returns the transform that reorders the bands of grids of g, keeping the rows
+// within each band in place. order lists the old bands, counted from 0 at the top, in their new order.
+func (g *Geometry) BandPermutation(order []int) (*Transform, error) {
+	if err := g.checkBands("reordering bands"); err != nil {
+		return nil, err
+	}
+	if err := checkPermutation("band order", order, g.size/g.boxRows); err != nil {
+		return nil, err
+	}
+	newBand := inverse(order)
+	return moveCells(g, func(row, col int) (int, int) {
+		return newBand[row/g.boxRows]*g.boxRows + row%g.boxRows, col
+	}), nil
+}
+
+// ColPermutation is like RowPermutation for the columns within a stack,
+// the stack counted from 0 at the left.
+func (g *Geometry) ColPermutation(stack int, order []int) (*Transform, error) {
+	if err := g.checkBands("reordering columns"); err != nil {
+		return nil, err
+	}
+	if stack < 0 || stack >= g.size/g.boxCols {
+		return nil, fmt.Errorf("%w: stack %d must be in range [0, %d)", ErrInvalidTransform, stack, g.size/g.boxCols)
+	}
+	if err := checkPermutation("column order", order, g.boxCols); err != nil {
+		return nil, err
+	}
+	newCol := inverse(order)
+	return moveCells(g, func(row, col int) (int, int) {
+		if col/g.boxCols != stack {
+			return row, col
+		}
+		return row, stack*g.boxCols + newCol[col%g.boxCols]
+	}), nil
+}
+
+// StackPermutation is like BandPermutation for the stacks, counted from 0 at the left.
+func (g *Geometry) StackPermutation(order []int) (*Transform, error) {
+	if err := g.checkBands("reordering stacks"); err != nil {
+		return nil, err
+	}
+	if err := checkPermutation("stack order", order, g.size/g.boxCols); err != nil {
+		return nil, err
+	}
+	newStack := inverse(order)
+	return moveCells(g, func(row, col int) (int, int) {
+		return row, newStack[col/g.boxCols]*g.boxCols + col%g.boxCols
+	}), nil
+}
+
+// RandomTransform returns a transform of grids of g chosen uniformly at random among the relabelings,
+// row, band, column and stack orders, and, for square boxes, transpositions, which between them
+// make every rotation and reflection too. Returns an error for jigsaw grids, which have no bands or stacks.
+func (g *Geometry) RandomTransform(rng *rand.Rand) (*Transform, error) {
+	if err := g.checkBands("a random transform"); err != nil {
+		return nil, err
+	}
+	t := moveCells(g, func(row, col int) (int, int) { return row, col })
+
+	// Build the orders straight from random permutations, which are valid by construction
+	for i, d := range rng.Perm(g.size) {
+		t.digits[i+1] = d + 1
+	}
+	bands, stacks := rng.Perm(g.size/g.boxRows), rng.Perm(g.size/g.boxCols)
+	var rows, cols []int
+	for _, band := range bands {
+		for _, r := range rng.Perm(g.boxRows) {
+			rows = append(rows, band*g.boxRows+r)
+		}
+	}
+	for _, stack := range stacks {
+		for _, c := range rng.Perm(g.boxCols) {
+			cols = append(cols, stack*g.boxCols+c)
+		}
+	}
+	newRow, newCol := inverse(rows), inverse(cols)
+	transpose := g.boxRows == g.boxCols && rng.Intn(2) == 1
+
+	for pos := 0; pos < g.cells; pos++ {
+		row, col := newRow[g.rowOf[pos]], newCol[g.colOf[pos]]
+		if transpose {
+			row, col = col, row
+		}
+		t.to[pos] = g.MakePos(row, col)
+		t.from[t.to[pos]] = pos
+	}
+	return t, nil
+}
+
+// Then returns the transform that applies t and then u.
+// Returns an error if u does not apply to the grids t produces.
+func (t *Transform) Then(u *Transform) (*Transform, error) {
+	if !sameShape(t.geo, u.geo) {
+		return nil, fmt.Errorf("%w: cannot follow a transform of %s grids with one of %s grids", ErrInvalidTransform, t.geo, u.geo)
+	}
+	c := &Transform{
+		geo:  t.geo,
+		from: make([]int, len(t.from)),
+		to:   make([]int, len(t.to)),
+	}
+	for pos := range t.to {
+		c.to[pos] = u.to[t.to[pos]]
+		c.from[c.to[pos]] = pos
+	}
+	for val := range c.digits {
+		c.digits[val] = u.digits[t.digits[val]]
+	}
+	return c, nil
+}
+
+// Inverse returns the transform that undoes t.
+func (t *Transform) Inverse() *Transform {
+	inv := &Transform{
+		geo:  t.geo,
+		from: slices.Clone(t.to),
+		to:   slices.Clone(t.from),
+	}
+	for val, d := range t.digits {
+		inv.digits[d] = val
+	}
+	return inv
+}
+
+// Cell returns the new position of the cell at pos, or InvalidCell if pos is out of bounds.
+func (t *Transform) Cell(pos int) int {
+	if pos < 0 || pos >= len(t.to) {
+		return InvalidCell
+	}
+	return t.to[pos]
+}
+
+// Digit returns the new digit of val, EmptyCell for EmptyCell, or InvalidCell if val is not a digit.
+func (t *Transform) Digit(val int) int {
+	if val < 0 || val > t.geo.size {
+		return InvalidCell
+	}
+	return t.digits[val]
+}
+
+// Apply returns the board b turns into under t, pencil marks included.
+// Returns an error if b is not of the shape t applies to, or is a jigsaw or has extra units,
+// rules or constraints, which the transforms do not all preserve.
+func (t *Transform) Apply(b *Board) (*Board, error) {
+	if !b.transformable() {
+		return nil, fmt.Errorf("%w: %s puzzles change under transforms", ErrUnsupportedTransform, b.geo)
+	}
+	if !sameShape(b.geo, t.geo) {
+		return nil, fmt.Errorf("%w: transform of %s grids applied to %s", ErrUnsupportedTransform, t.geo, b.geo)
+	}
+
+	out := NewWithGeometry(b.geo)
+	for pos, val := range b.cells {
+		newPos := t.to[pos]
+		if val != EmptyCell {
+			out.SetForce(newPos, t.digits[val])
+		}
+		out.marks[newPos] = 0
+		for m := b.marks[pos]; m != 0; m &= m - 1 {
+			val := bits.TrailingZeros(m) + 1
+			out.marks[newPos] |= 1 << (t.digits[val] - 1)
+		}
+	}
+	return out, nil
+}
+
+// transformable reports whether b is a plain grid, which every transform turns into another puzzle
+// with correspondingly many solutions. Jigsaw regions, extra units, rules and constraints are tied
+// to cells that rows, bands and columns cannot always be reordered around.
+func (b *Board) transformable() bool {
+	g := b.geo
+	return !g.Jigsaw() && g.extras == 0 && g.rules == 0 && len(b.Constraints()) == 0
+}
+
+// sameShape reports whether two geometries have the same size and box shape.
+func sameShape(a, b *Geometry) bool {
+	return a.size == b.size && a.boxRows == b.boxRows && a.boxCols == b.boxCols
+}
+
+// transformed applies a transform made for b's geometry to b.
+func (b *Board) transformed(t *Transform, err error) (*Board, error) {
+	if err != nil {
+		return nil, err
+	}
+	return t.Apply(b)
+}
+
+// Rotate returns the board turned clockwise by the given number of quarter turns; see Geometry.Rotation.
+func (b *Board) Rotate(turns int) (*Board, error) {
+	return b.transformed(b.geo.Rotation(turns))
+}
+
+// ReflectHorizontal returns the board mirrored across its middle row.
+func (b *Board) ReflectHorizontal() (*Board, error) {
+	return b.transformed(b.geo.HorizontalReflection(), nil)
+}
+
+// ReflectVertical returns the board mirrored across its middle column.
+func (b *Board) ReflectVertical() (*Board, error) {
+	return b.transformed(b.geo.VerticalReflection(), nil)
+}
+
+// Transpose returns the board mirrored across its main diagonal.
+func (b *Board) Transpose() (*Board, error) {
+	return b.transformed(b.geo.Transposition())
+}
+
+// Relabel returns the board with each digit d replaced by digits[d-1]; see Geometry.Relabeling.
+func (b *Board) Relabel(digits []int) (*Board, error) {
+	return b.transformed(b.geo.Relabeling(digits))
+}
+
+// PermuteRowsInBand returns the board with the rows of a band reordered; see Geometry.RowPermutation.
+func (b *Board) PermuteRowsInBand(band int, order []int) (*Board, error) {
+	return b.transformed(b.geo.RowPermutation(band, order))
+}
+
+// PermuteBands returns the board with its bands reordered; see Geometry.BandPermutation.
+func (b *Board) PermuteBands(order []int) (*Board, error) {
+	return b.transformed(b.geo.BandPermutation(order))
+}
+
+// PermuteColsInStack returns the board with the columns of a stack reordered; see Geometry.ColPermutation.
+func (b *Board) PermuteColsInStack(stack int, order []int) (*Board, error) {
+	return b.transformed(b.geo.ColPermutation(stack, order))
+}
+
+// PermuteStacks returns the board with its stacks reordered; see Geometry.StackPermutation.
+func (b *Board) PermuteStacks(order []int) (*Board, error) {
+	return b.transformed(b.geo.StackPermutation(order))
+}
+
+// RandomTransform returns a random puzzle equivalent to b along with the transform that made it,
+// whose inverse maps answers on the new puzzle back to b; see Geometry.RandomTransform.
+func (b *Board) RandomTransform(rng *rand.Rand) (*Board, *Transform, error) {
+	if !b.transformable() {
+		return nil, nil, fmt.Errorf("%w: %s puzzles change under transforms", ErrUnsupportedTransform, b.geo)
+	}
+	t, err := b.geo.RandomTransform(rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := t.Apply(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, t, nil
+}
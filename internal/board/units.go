@@ -0,0 +1,194 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrInvalidCellName = errors.New("invalid cell name")
+
+// UnitType identifies the kind of unit a group of cells forms.
+type UnitType int
+
+const (
+	RowUnit UnitType = iota
+	ColUnit
+	BoxUnit
+	DiagonalUnit
+	WindowUnit
+	CentreDotUnit
+)
+
+// String returns the lowercase name of the unit type.
+func (t UnitType) String() string {
+	switch t {
+	case RowUnit:
+		return "row"
+	case ColUnit:
+		return "column"
+	case BoxUnit:
+		return "box"
+	case DiagonalUnit:
+		return "diagonal"
+	case WindowUnit:
+		return "window"
+	case CentreDotUnit:
+		return "centre dot"
+	default:
+		return fmt.Sprintf("UnitType(%d)", int(t))
+	}
+}
+
+// Unit is a row, column, box or extra unit: one cell per digit, all holding distinct values.
+type Unit struct {
+	Type  UnitType
+	Index int
+	Cells []int
+}
+
+// String returns the unit in 1-based human notation, e.g. "box 5".
+func (u Unit) String() string {
+	return fmt.Sprintf("%s %d", u.Type, u.Index+1)
+}
+
+// Units returns every unit: rows, then columns, then boxes, each in index order,
+// followed by any extra units.
+// The returned slice is shared and must not be modified.
+func (g *Geometry) Units() []Unit {
+	return g.units
+}
+
+// UnitsOf returns the indices into Units of the units containing pos.
+// The returned slice is shared and must not be modified.
+func (g *Geometry) UnitsOf(pos int) []int {
+	return g.unitsOf[pos]
+}
+
+// RowUnitOf returns the row unit containing pos.
+func (g *Geometry) RowUnitOf(pos int) Unit {
+	return g.units[g.rowOf[pos]]
+}
+
+// ColUnitOf returns the column unit containing pos.
+func (g *Geometry) ColUnitOf(pos int) Unit {
+	return g.units[g.size+g.colOf[pos]]
+}
+
+// BoxUnitOf returns the box unit containing pos.
+func (g *Geometry) BoxUnitOf(pos int) Unit {
+	return g.units[2*g.size+g.boxOf[pos]]
+}
+
+// RowOf returns the 0-based row index of a position.
+func (g *Geometry) RowOf(pos int) int {
+	return g.rowOf[pos]
+}
+
+// ColOf returns the 0-based column index of a position.
+func (g *Geometry) ColOf(pos int) int {
+	return g.colOf[pos]
+}
+
+// BoxOf returns the 0-based box index of a position, counting boxes left to right, top to bottom.
+func (g *Geometry) BoxOf(pos int) int {
+	return g.boxOf[pos]
+}
+
+// Peers returns the cells that may not hold the same digit as pos: those sharing a unit
+// with it, or a move apart under the rules.
+// The returned slice is shared and must not be modified.
+func (g *Geometry) Peers(pos int) []int {
+	return g.peers[pos]
+}
+
+// Sees reports whether two distinct cells share a unit, or are a move apart under the rules.
+func (g *Geometry) Sees(a, b int) bool {
+	return g.sharesUnit(a, b) || slices.Contains(g.movePeers[a], b)
+}
+
+// sharesUnit reports whether two distinct cells share a unit.
+func (g *Geometry) sharesUnit(a, b int) bool {
+	if a == b {
+		return false
+	}
+	if g.rowOf[a] == g.rowOf[b] || g.colOf[a] == g.colOf[b] || g.boxOf[a] == g.boxOf[b] {
+		return true
+	}
+	for _, u := range g.unitsOf[a][3:] {
+		if slices.Contains(g.unitsOf[b][3:], u) {
+			return true
+		}
+	}
+	return false
+}
+
+// Neighbors returns the cells orthogonally adjacent to pos, in position order.
+func (g *Geometry) Neighbors(pos int) []int {
+	row, col := g.rowOf[pos], g.colOf[pos]
+	var cells []int
+	for _, d := range [][2]int{{-1, 0}, {0, -1}, {0, 1}, {1, 0}} {
+		if n := g.MakePos(row+d[0], col+d[1]); n != InvalidCell {
+			cells = append(cells, n)
+		}
+	}
+	return cells
+}
+
+// CellName returns the 1-based "r<row>c<col>" name of a position.
+func (g *Geometry) CellName(pos int) string {
+	return fmt.Sprintf("r%dc%d", g.rowOf[pos]+1, g.colOf[pos]+1)
+}
+
+// ParseCellName is the inverse of CellName, converting "r<row>c<col>" into a position.
+func (g *Geometry) ParseCellName(name string) (int, error) {
+	var row, col int
+	if _, err := fmt.Sscanf(name, "r%dc%d", &row, &col); err != nil {
+		return InvalidCell, fmt.Errorf("%w: %q", ErrInvalidCellName, name)
+	}
+	pos := g.MakePos(row-1, col-1)
+	if pos == InvalidCell || g.CellName(pos) != name {
+		return InvalidCell, fmt.Errorf("%w: %q on a %s grid", ErrInvalidCellName, name, g)
+	}
+	return pos, nil
+}
+
+// initUnits builds the unit and peer tables from the position lookup tables and the rules.
+func (g *Geometry) initUnits() {
+	n := g.size
+	g.units = make([]Unit, 3*n)
+	for i := 0; i < n; i++ {
+		g.units[i] = Unit{Type: RowUnit, Index: i}
+		g.units[n+i] = Unit{Type: ColUnit, Index: i}
+		g.units[2*n+i] = Unit{Type: BoxUnit, Index: i}
+	}
+
+	g.unitsOf = make([][]int, g.cells)
+	for pos := 0; pos < g.cells; pos++ {
+		row, col, box := g.rowOf[pos], g.colOf[pos], g.boxOf[pos]
+		g.unitsOf[pos] = []int{row, n + col, 2*n + box}
+		for _, u := range g.unitsOf[pos] {
+			g.units[u].Cells = append(g.units[u].Cells, pos)
+		}
+	}
+
+	extra := g.extraUnitCells()
+	for _, t := range []UnitType{DiagonalUnit, WindowUnit, CentreDotUnit} {
+		for i, cells := range extra[t] {
+			for _, pos := range cells {
+				g.unitsOf[pos] = append(g.unitsOf[pos], len(g.units))
+			}
+			g.units = append(g.units, Unit{Type: t, Index: i, Cells: cells})
+		}
+	}
+
+	g.initMovePeers()
+	g.peers = make([][]int, g.cells)
+	for pos := 0; pos < g.cells; pos++ {
+		for other := 0; other < g.cells; other++ {
+			if g.Sees(pos, other) {
+				g.peers[pos] = append(g.peers[pos], other)
+			}
+		}
+	}
+}
@@ -7,61 +7,78 @@ import (
 
 var (
 	ErrInvalidPosition = errors.New("position out of bounds")
-	ErrInvalidValue    = errors.New("value must be between 1-9")
+	ErrInvalidValue    = errors.New("value is not a digit of the grid")
 	ErrIllegalMove     = errors.New("move violates Sudoku constraints")
 )
 
-// IsValid reports whether a board satisfies Sudoku constraints.
+// IsValid reports whether a board satisfies Sudoku constraints, along with any rules and extra constraints.
 // Empty cells are ignored for validation.
 func (b *Board) IsValid() bool {
-	var rowCheck, colCheck, boxCheck [9]uint
+	check := make([]uint, len(b.geo.units))
 
-	for pos := 0; pos < CellCount; pos++ {
-		val := b.Get(pos)
+	for pos, val := range b.cells {
 		if val == EmptyCell {
 			continue
 		}
 
-		row, col, box := posToRow[pos], posToCol[pos], posToBox[pos]
 		mask := uint(1 << (val - 1))
 
 		// Check for duplicates
-		if rowCheck[row]&mask != 0 ||
-			colCheck[col]&mask != 0 ||
-			boxCheck[box]&mask != 0 {
-			return false
+		for _, u := range b.geo.unitsOf[pos] {
+			if check[u]&mask != 0 {
+				return false
+			}
+			check[u] |= mask
+		}
+		for _, other := range b.geo.movePeers[pos] {
+			if b.cells[other] == val {
+				return false
+			}
 		}
+	}
 
-		rowCheck[row] |= mask
-		colCheck[col] |= mask
-		boxCheck[box] |= mask
+	for _, c := range b.Constraints() {
+		if !c.Check(b) {
+			return false
+		}
 	}
 
 	return true
 }
 
-// isValidPosition reports whether a given position is in bounds of a Sudoku board.
-func isValidPosition(pos int) bool {
-	return pos >= 0 && pos < CellCount
+// isValidPosition reports whether a given position is in bounds of the board.
+func (b *Board) isValidPosition(pos int) bool {
+	return pos >= 0 && pos < b.geo.cells
 }
 
 // validatePosition checks if a position is within board bounds.
 func (b *Board) validatePosition(pos int) error {
-	if !isValidPosition(pos) {
-		return fmt.Errorf("%w: position %d must be in range [0, %d)", ErrInvalidPosition, pos, CellCount)
+	if !b.isValidPosition(pos) {
+		return fmt.Errorf("%w: position %d must be in range [0, %d)", ErrInvalidPosition, pos, b.geo.cells)
 	}
 	return nil
 }
 
-// isValidValue reports whether a given number is valid on a Sudoku board.
-func isValidValue(num int) bool {
-	return (num >= 1 && num <= 9) || num == EmptyCell
+// isValidValue reports whether a given number is a digit of the board or empty.
+func (b *Board) isValidValue(num int) bool {
+	return (num >= 1 && num <= b.geo.size) || num == EmptyCell
 }
 
-// validateValue checks if a value is valid for Sudoku (1-9).
+// validateValue checks if a value is a digit 1 to Size, or empty.
 func (b *Board) validateValue(val int) error {
-	if !isValidValue(val) {
-		return fmt.Errorf("%w: got %d", ErrInvalidValue, val)
+	if !b.isValidValue(val) {
+		return fmt.Errorf("%w: got %d, want 1-%d", ErrInvalidValue, val, b.geo.size)
 	}
 	return nil
 }
+
+// validateCandidate checks that a position is in bounds and a candidate is a digit of the board.
+func (b *Board) validateCandidate(pos, val int) error {
+	if err := b.validatePosition(pos); err != nil {
+		return err
+	}
+	if val == EmptyCell {
+		return fmt.Errorf("%w: got %d, want 1-%d", ErrInvalidValue, val, b.geo.size)
+	}
+	return b.validateValue(val)
+}
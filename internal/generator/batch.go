@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"context"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/rating"
+	"iter"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// BatchResult is one puzzle of a batch.
+type BatchResult struct {
+	Index    int            // Index is the position of the puzzle in the batch
+	Seed     int64          // Seed reproduces the puzzle with Generate
+	Puzzle   *board.Board   // Puzzle is nil if generation failed
+	Solution *board.Board   // Solution is nil if generation failed
+	Rating   *rating.Rating // Rating is nil unless asked for with Rate or Target, or if the puzzle could not be rated
+	Elapsed  time.Duration  // Elapsed is the time spent generating the puzzle
+	Err      error          // Err reports why generation failed
+}
+
+// batchJob asks a worker for one puzzle, to be delivered on slot.
+type batchJob struct {
+	index int
+	slot  chan<- BatchResult
+}
+
+// GenerateBatch generates n puzzles on a pool of workers and yields them in order.
+// Puzzle i is generated from seed BatchSeed(opts.Seed, i), or from a random master seed
+// if opts.Seed is 0, so a batch comes out the same whatever the number of workers.
+// Workers defaults to one per CPU. A failed puzzle is yielded with Err set and the batch goes on.
+// The batch stops early once the caller stops iterating, or once ctx is done,
+// in which case callers should check ctx.Err() for the puzzles left out.
+func GenerateBatch(ctx context.Context, n int, opts *Options, workers int) iter.Seq[BatchResult] {
+	if opts == nil {
+		opts = DefaultOptions(DefaultClueCount)
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	master := opts.Seed
+	for master == 0 {
+		master = time.Now().UnixNano()
+	}
+
+	return func(yield func(BatchResult) bool) {
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// pending holds result slots in batch order; its capacity bounds how far
+		// workers run ahead of a slow consumer
+		jobs := make(chan batchJob)
+		pending := make(chan chan BatchResult, 2*workers)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(pending)
+			defer close(jobs)
+			for i := 0; i < n; i++ {
+				slot := make(chan BatchResult, 1)
+				select {
+				case jobs <- batchJob{index: i, slot: slot}:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case pending <- slot:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range jobs {
+					job.slot <- generateOne(ctx, opts, job.index, BatchSeed(master, job.index))
+				}
+			}()
+		}
+
+		for slot := range pending {
+			select {
+			case r := <-slot:
+				if !yield(r) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// BatchSeed returns the seed of puzzle i in a batch with the given master seed: master+i,
+// skipping 0 since a zero seed asks for a random one.
+func BatchSeed(master int64, i int) int64 {
+	seed := master + int64(i)
+	if master < 0 && seed >= 0 {
+		seed++
+	}
+	return seed
+}
+
+// generateOne generates the puzzle at index in a batch with its own generator,
+// and rates it if asked to within what is left of its timeout.
+func generateOne(ctx context.Context, opts *Options, index int, seed int64) BatchResult {
+	o := *opts
+	o.Seed = seed
+
+	start := time.Now()
+	puzzle, solution, err := New(&o).GenerateContext(ctx)
+	res := BatchResult{
+		Index:    index,
+		Seed:     seed,
+		Puzzle:   puzzle,
+		Solution: solution,
+		Elapsed:  time.Since(start),
+		Err:      err,
+	}
+	if err == nil && (o.Rate || o.Target != nil) {
+		if o.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, start.Add(o.Timeout))
+			defer cancel()
+		}
+		// A puzzle the rater cannot handle, or not in time, is left unrated
+		res.Rating, _ = rating.RateContext(ctx, puzzle)
+	}
+	return res
+}
@@ -0,0 +1,268 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownClueKinds = errors.New("unknown clue kinds")
+
+// ClueKinds is a set of variant clues to add to generated puzzles.
+type ClueKinds uint
+
+const (
+	SandwichClues     ClueKinds = 1 << iota // Sandwich sums for every row and column
+	ArrowClues                              // Arrows whose bulbs hold the sum of their shafts
+	ThermoClues                             // Thermometers of increasing digits
+	LittleKillerClues                       // Sums along diagonals, given outside the grid
+)
+
+// clueKindNames holds the name of each clue kind flag, in bit order.
+var clueKindNames = [...]string{"sandwich", "arrow", "thermo", "little-killer"}
+
+// String returns the names of the clue kinds joined by commas, or "none".
+func (k ClueKinds) String() string {
+	var names []string
+	for i, name := range clueKindNames {
+		if k&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseClueKinds converts comma separated names such as "sandwich,thermo" into ClueKinds.
+// Names are matched case-insensitively; "" and "none" select no clues.
+func ParseClueKinds(s string) (ClueKinds, error) {
+	var k ClueKinds
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "none") {
+			continue
+		}
+		i := -1
+		for j, n := range clueKindNames {
+			if strings.EqualFold(name, n) {
+				i = j
+			}
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("%w: %q", ErrUnknownClueKinds, name)
+		}
+		k |= 1 << i
+	}
+	return k, nil
+}
+
+// Longest generated lines, kept short so that each says something about its cells
+const (
+	maxThermoLength = 6
+	maxArrowShaft   = 3
+)
+
+// lineTries is how many random walks may miss before a kind of line has to make do with fewer lines.
+const lineTries = 100
+
+// addClues adds variant clues of the kinds in Clues that hold for the solution.
+// Arrows, thermometers and little killers share about one clue per row, enough for
+// far fewer givens than a plain puzzle needs. Arrows and thermometers do not cross,
+// and little killers take the shortest diagonals, whose sums say the most about their cells.
+func (g *Generator) addClues(puzzle, solution *board.Board) error {
+	geo := solution.Geometry()
+	kinds := g.options.Clues
+	var clues []board.Constraint
+
+	if kinds&SandwichClues != 0 {
+		for _, u := range geo.Units()[:2*geo.Size()] {
+			s, err := board.NewSandwich(geo, u, sandwichSum(solution, u))
+			if err != nil {
+				return err
+			}
+			clues = append(clues, s)
+		}
+	}
+
+	// Lines and diagonals share a budget of one per row, split between the kinds
+	perKind := 0
+	if n := bits.OnesCount(uint(kinds &^ SandwichClues)); n > 0 {
+		perKind = geo.Size() / n
+	}
+
+	used := make([]bool, geo.CellCount())
+	if kinds&ArrowClues != 0 {
+		for tries, added := 0, 0; added < perKind && tries < lineTries; tries++ {
+			if cells := g.arrowLine(solution, used); cells != nil {
+				a, err := board.NewArrow(geo, cells)
+				if err != nil {
+					return err
+				}
+				clues = append(clues, a)
+				added++
+			}
+		}
+	}
+	if kinds&ThermoClues != 0 {
+		for tries, added := 0, 0; added < perKind && tries < lineTries; tries++ {
+			if cells := g.thermoLine(solution, used); cells != nil {
+				t, err := board.NewThermometer(geo, cells)
+				if err != nil {
+					return err
+				}
+				clues = append(clues, t)
+				added++
+			}
+		}
+	}
+
+	if kinds&LittleKillerClues != 0 {
+		diagonals := fullDiagonals(geo)
+		g.rng.Shuffle(len(diagonals), func(i, j int) {
+			diagonals[i], diagonals[j] = diagonals[j], diagonals[i]
+		})
+		slices.SortStableFunc(diagonals, func(a, b []int) int { return len(a) - len(b) })
+		for _, cells := range diagonals[:min(perKind, len(diagonals))] {
+			sum := 0
+			for _, pos := range cells {
+				sum += solution.Get(pos)
+			}
+			lk, err := board.NewLittleKiller(geo, sum, cells)
+			if err != nil {
+				return err
+			}
+			clues = append(clues, lk)
+		}
+	}
+
+	for _, c := range clues {
+		if err := puzzle.AddConstraint(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sandwichSum returns the sum of the digits between the 1 and the largest digit of a solved unit.
+func sandwichSum(solution *board.Board, u board.Unit) int {
+	one, top := -1, -1
+	for i, pos := range u.Cells {
+		switch solution.Get(pos) {
+		case 1:
+			one = i
+		case len(u.Cells):
+			top = i
+		}
+	}
+	sum := 0
+	for _, pos := range u.Cells[min(one, top)+1 : max(one, top)] {
+		sum += solution.Get(pos)
+	}
+	return sum
+}
+
+// thermoLine walks a random thermometer through unused cells of the solution,
+// each step going to a touching cell with a larger digit, and marks its cells used.
+// Returns nil if the walk from a random start stays shorter than three cells.
+func (g *Generator) thermoLine(solution *board.Board, used []bool) []int {
+	geo := solution.Geometry()
+	start := g.rng.Intn(geo.CellCount())
+	if used[start] {
+		return nil
+	}
+	cells := []int{start}
+	for len(cells) < min(maxThermoLength, geo.Size()) {
+		last := cells[len(cells)-1]
+		next := touching(geo, last, used, cells, func(pos int) bool {
+			return solution.Get(pos) > solution.Get(last)
+		})
+		if len(next) == 0 {
+			break
+		}
+		cells = append(cells, next[g.rng.Intn(len(next))])
+	}
+	if len(cells) < 3 {
+		return nil
+	}
+	for _, pos := range cells {
+		used[pos] = true
+	}
+	return cells
+}
+
+// arrowLine walks a random arrow through unused cells of the solution, from a bulb
+// along a shaft whose digits add up to the bulb's, and marks its cells used.
+// Returns nil if the walk from a random bulb misses the sum.
+func (g *Generator) arrowLine(solution *board.Board, used []bool) []int {
+	geo := solution.Geometry()
+	bulb := g.rng.Intn(geo.CellCount())
+	if used[bulb] {
+		return nil
+	}
+	cells := []int{bulb}
+	rem := solution.Get(bulb)
+	for rem > 0 && len(cells) <= maxArrowShaft {
+		last := cells[len(cells)-1]
+		next := touching(geo, last, used, cells, func(pos int) bool {
+			// A shaft of one cell would just repeat the bulb's digit
+			return solution.Get(pos) < rem || solution.Get(pos) == rem && len(cells) > 1
+		})
+		if len(next) == 0 {
+			return nil
+		}
+		pos := next[g.rng.Intn(len(next))]
+		cells = append(cells, pos)
+		rem -= solution.Get(pos)
+	}
+	if rem != 0 {
+		return nil
+	}
+	for _, pos := range cells {
+		used[pos] = true
+	}
+	return cells
+}
+
+// touching returns the cells orthogonally or diagonally next to pos that are not used,
+// not already on the line, and accepted by ok.
+func touching(geo *board.Geometry, pos int, used []bool, line []int, ok func(pos int) bool) []int {
+	var cells []int
+	row, col := geo.RowOf(pos), geo.ColOf(pos)
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			n := geo.MakePos(row+dr, col+dc)
+			if n == board.InvalidCell || n == pos || used[n] || slices.Contains(line, n) || !ok(n) {
+				continue
+			}
+			cells = append(cells, n)
+		}
+	}
+	return cells
+}
+
+// fullDiagonals returns every diagonal of at least three cells running from edge to edge,
+// each listed downwards.
+func fullDiagonals(geo *board.Geometry) [][]int {
+	var diagonals [][]int
+	for _, d := range [][2]int{{1, 1}, {1, -1}} {
+		for pos := 0; pos < geo.CellCount(); pos++ {
+			row, col := geo.RowOf(pos), geo.ColOf(pos)
+			if geo.MakePos(row-d[0], col-d[1]) != board.InvalidCell {
+				continue
+			}
+			var cells []int
+			for p := pos; p != board.InvalidCell; p = geo.MakePos(geo.RowOf(p)+d[0], geo.ColOf(p)+d[1]) {
+				cells = append(cells, p)
+			}
+			if len(cells) >= 3 {
+				diagonals = append(diagonals, cells)
+			}
+		}
+	}
+	return diagonals
+}
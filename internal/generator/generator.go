@@ -1,13 +1,17 @@
 package generator
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/rating"
 	"github.com/rybkr/sudoku/internal/solver"
 	"math/rand"
 	"time"
 )
 
+// Clue counts of standard 9x9 puzzles; see ClueCountRange and DefaultClueCountFor for other sizes
 const (
 	MinValidClueCount = 17
 	MaxValidClueCount = 80
@@ -16,8 +20,9 @@ const (
 
 var (
 	ErrGenerationFailed = errors.New("failed to generate valid puzzle")
-	ErrInvalidClueCount = errors.New("clue count must be between 17 and 80")
+	ErrInvalidClueCount = errors.New("clue count out of range")
 	ErrDiggingFailed    = errors.New("failed to remove proper number of clues")
+	ErrTargetMissed     = errors.New("puzzle missed the target difficulty")
 )
 
 // Generator creates Sudoku puzzles.
@@ -46,90 +51,145 @@ func New(options *Options) *Generator {
 // Generate creates a new Sudoku puzzle.
 // Returns the puzzle and its solution, or an error if generation fails.
 func (g *Generator) Generate() (puzzle *board.Board, solution *board.Board, err error) {
-	if g.options.ClueCount < MinValidClueCount || g.options.ClueCount > MaxValidClueCount {
-		return nil, nil, ErrInvalidClueCount
+	return g.GenerateContext(context.Background())
+}
+
+// GenerateContext is like Generate but stops promptly once ctx is done.
+// Running out of time, from ctx or the Timeout option, fails with ErrGenerationFailed
+// wrapping solver.ErrTimeout; cancellation returns ctx.Err().
+func (g *Generator) GenerateContext(ctx context.Context) (puzzle *board.Board, solution *board.Board, err error) {
+	if err := g.options.Validate(); err != nil {
+		return nil, nil, err
 	}
 
-	start := time.Now()
-	timeout := g.options.Timeout
+	if g.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.options.Timeout)
+		defer cancel()
+	}
 
 	for {
-		if time.Since(start) >= timeout {
-			return nil, nil, ErrGenerationFailed
+		if ctx.Err() != nil {
+			return nil, nil, contextError(ctx)
 		}
 
 		// Generate a complete valid board
-		solution, err = g.generateSolution()
+		solution, err = g.generateSolution(ctx)
 		if err != nil {
 			continue
 		}
 
-		// Remove clues to create the puzzle
-		puzzle, err = g.removeCells(solution)
+		// Remove clues to create the puzzle, or constrain a killer or variant puzzle which checks its own uniqueness
+		switch {
+		case g.options.Killer || g.options.Clues != 0:
+			puzzle, err = g.constrain(ctx, solution)
+		case g.options.Target != nil:
+			puzzle, err = g.digToTarget(ctx, solution)
+		default:
+			puzzle, err = g.removeCells(ctx, solution)
+		}
 		if err != nil {
 			continue
 		}
 
 		// Verify uniqueness if required
-		if g.options.EnsureUnique {
-			if !g.hasUniqueSolution(puzzle) {
+		if g.options.EnsureUnique && !g.options.Killer && g.options.Clues == 0 {
+			if !g.hasUniqueSolution(ctx, puzzle) {
 				continue
 			}
 		}
 
 		return puzzle, solution, nil
 	}
-
-	return nil, nil, ErrGenerationFailed
 }
 
-// generateSolution creates a complete valid Sudoku board.
-func (g *Generator) generateSolution() (*board.Board, error) {
-	b := board.New()
+// generateSolution creates a complete valid Sudoku board,
+// on a new random layout for jigsaw puzzles.
+func (g *Generator) generateSolution(ctx context.Context) (*board.Board, error) {
+	geo := g.geometry()
+	if g.options.Jigsaw {
+		var err error
+		if geo, err = g.jigsawGeometry(geo); err != nil {
+			return nil, err
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, jigsawSolveTime*time.Duration(geo.CellCount()))
+		defer cancel()
+	}
+	b := board.NewWithGeometry(geo)
 
-	// Use solver with randomization to generate a complete board
-	s := solver.New(b, &solver.Options{
+	// Use solver with randomization to generate a complete board.
+	// Sharing the generator's source keeps the whole run reproducible from one seed.
+	opts := &solver.Options{
 		MaxSolutions: 1,
 		Randomize:    true,
-		Timeout:      g.options.Timeout,
-	})
+		Source:       g.rng,
+	}
+	if g.options.Jigsaw {
+		// Irregular regions defeat backtracking far more often than an exact cover search
+		opts.Algorithm = solver.DancingLinks
+	}
 
-	return s.Solve()
+	return solver.New(b, opts).SolveContext(ctx)
+}
+
+// constrain turns a solution into a puzzle of killer cages and variant clues,
+// with as few givens as it takes to make the solution unique.
+// Killer puzzles start out with no givens and gain them. Other puzzles are first dug
+// as far as ClueCount without their clues, which the exact cover search can check far quicker,
+// then lose whatever givens the clues make redundant.
+func (g *Generator) constrain(ctx context.Context, solution *board.Board) (*board.Board, error) {
+	var puzzle *board.Board
+	if g.options.Killer {
+		puzzle = board.NewWithGeometry(solution.Geometry())
+		if err := g.addCages(puzzle, solution); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if puzzle, err = g.removeCells(ctx, solution); err != nil && !errors.Is(err, ErrDiggingFailed) {
+			return nil, err
+		}
+	}
+	if err := g.addClues(puzzle, solution); err != nil {
+		return nil, err
+	}
+	return g.settle(ctx, puzzle, solution)
 }
 
 // removeCells removes clues from a complete board to create a puzzle.
-func (g *Generator) removeCells(solution *board.Board) (*board.Board, error) {
+func (g *Generator) removeCells(ctx context.Context, solution *board.Board) (*board.Board, error) {
 	puzzle := solution.Clone()
 
 	// Calculate how many cells to remove
 	targetClues := g.options.ClueCount
-	cellsToRemove := board.CellCount - targetClues
-
-	// Create shuffled list of all positions
-	positions := g.rng.Perm(board.CellCount)
+	cellsToRemove := g.geometry().CellCount() - targetClues
 
 	// Remove cells until we reach target clues
 	cellsRemoved := 0
-	for _, pos := range positions {
+	for _, group := range g.digOrder() {
 		if cellsRemoved >= cellsToRemove {
 			break
 		}
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 
-		// Try removing this cell
-		val := puzzle.Get(pos)
-		if val == board.EmptyCell {
+		// Symmetric groups must come out whole, skip those that overshoot
+		if len(group) > cellsToRemove-cellsRemoved {
 			continue
 		}
 
-		puzzle.Clear(pos)
-		cellsRemoved++
+		// Try removing this group
+		vals := clearGroup(puzzle, group)
+		cellsRemoved += len(group)
 
 		// Verify the puzzle still has a unique solution
 		if g.options.EnsureUnique {
-			if !g.hasUniqueSolution(puzzle) {
+			if !g.hasUniqueSolution(ctx, puzzle) {
 				// Restore the cells
-				puzzle.SetForce(pos, val)
-				cellsRemoved--
+				restoreGroup(puzzle, group, vals)
+				cellsRemoved -= len(group)
 			}
 		}
 	}
@@ -141,63 +201,120 @@ func (g *Generator) removeCells(solution *board.Board) (*board.Board, error) {
 	}
 }
 
-// hasUniqueSolution checks if the puzzle has exactly one solution.
-func (g *Generator) hasUniqueSolution(puzzle *board.Board) bool {
-	s := solver.New(puzzle, &solver.Options{
-		MaxSolutions: 2,
-		Randomize:    false,
-		Timeout:      g.options.Timeout,
-	})
-
-	solutions := g.countSolutions(s)
-	return solutions == 1
-}
+// digToTarget removes clues from a complete board for as long as the puzzle stays
+// within the target difficulty, then checks that it reached the target.
+func (g *Generator) digToTarget(ctx context.Context, solution *board.Board) (*board.Board, error) {
+	puzzle := solution.Clone()
+	target := g.options.Target
 
-// countSolutions counts the number of solutions for a puzzle.
-func (g *Generator) countSolutions(s *solver.Solver) int {
-	count := 0
+	for _, group := range g.digOrder() {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+		if puzzle.ClueCount()-len(group) < g.options.ClueCount {
+			continue
+		}
 
-	// Use backtracking to count solutions
-	var backtrack func(*board.Board) bool
-	backtrack = func(b *board.Board) bool {
-		// Apply constraint propagation
-		tempSolver := solver.New(b, &solver.Options{
-			MaxSolutions: 1,
-			Randomize:    false,
-		})
+		vals := clearGroup(puzzle, group)
 
-		if err := tempSolver.PropagateConstraints(); err != nil {
-			return false
+		if g.options.EnsureUnique && !g.hasUniqueSolution(ctx, puzzle) {
+			restoreGroup(puzzle, group, vals)
+			continue
 		}
 
-		// Check if solved
-		if tempSolver.Board.EmptyCount() == 0 {
-			count++
-			return count < 2 // Stop after finding 2 solutions
+		// Harder puzzles rarely get easier with fewer clues, so back out of overshoots
+		if target.bounded() {
+			if r, err := rating.RateContext(ctx, puzzle); err != nil || target.tooHard(r) {
+				restoreGroup(puzzle, group, vals)
+			}
 		}
+	}
+
+	r, err := rating.RateContext(ctx, puzzle)
+	if err != nil || !target.Accepts(r) {
+		return nil, ErrTargetMissed
+	}
+	return puzzle, nil
+}
 
-		// Find MRV cell
-		pos, candidates := tempSolver.FindMRVCell()
-		if len(candidates) == 0 {
-			return false
+// digOrder returns the groups of cells to try removing, in random order.
+// Without symmetry every group is a single cell.
+func (g *Generator) digOrder() [][]int {
+	if g.options.Symmetry == SymmetryNone {
+		var groups [][]int
+		for _, pos := range g.rng.Perm(g.geometry().CellCount()) {
+			groups = append(groups, []int{pos})
 		}
+		return groups
+	}
 
-		for _, val := range candidates {
-			if count >= 2 {
-				return false
-			}
+	groups := g.options.Symmetry.orbits(g.geometry())
+	g.rng.Shuffle(len(groups), func(i, j int) {
+		groups[i], groups[j] = groups[j], groups[i]
+	})
+	return groups
+}
 
-			clone := tempSolver.Board.Clone()
-			clone.SetForce(pos, val)
+// geometry returns the grid geometry being generated, the standard 9x9 grid by default.
+func (g *Generator) geometry() *board.Geometry {
+	return g.options.geometry()
+}
 
-			backtrack(clone)
-		}
+// ClueCountRange returns the fewest and most clues a generated puzzle may have.
+// A unique puzzle needs all but one digit among its clues, and 9x9 puzzles need 17.
+func ClueCountRange(g *board.Geometry) (lo, hi int) {
+	if g.Size() == board.Standard.Size() {
+		return MinValidClueCount, MaxValidClueCount
+	}
+	return g.Size() - 1, g.CellCount() - 1
+}
 
-		return count < 2
+// DefaultClueCountFor scales DefaultClueCount to the number of cells of a geometry.
+// Grids of 25x25 keep over half their cells, since proving uniqueness
+// with fewer clues takes the solver far longer than the default timeout.
+func DefaultClueCountFor(g *board.Geometry) int {
+	if g.Size() >= 25 {
+		return g.CellCount() * 54 / 100
 	}
+	return DefaultClueCount * g.CellCount() / board.Standard.CellCount()
+}
 
-	backtrack(s.Board.Clone())
-	return count
+// clearGroup empties a group of cells and returns their previous values.
+func clearGroup(puzzle *board.Board, group []int) []int {
+	vals := make([]int, len(group))
+	for i, pos := range group {
+		vals[i] = puzzle.Get(pos)
+		puzzle.Clear(pos)
+	}
+	return vals
+}
+
+// restoreGroup puts back the values removed by clearGroup.
+func restoreGroup(puzzle *board.Board, group []int, vals []int) {
+	for i, pos := range group {
+		puzzle.SetForce(pos, vals[i])
+	}
+}
+
+// hasUniqueSolution checks if the puzzle has exactly one solution.
+// Reports false if ctx is done before the count finishes.
+func (g *Generator) hasUniqueSolution(ctx context.Context, puzzle *board.Board) bool {
+	s := solver.New(puzzle, &solver.Options{
+		Algorithm:    solver.DancingLinks,
+		MaxSolutions: 2,
+		Randomize:    false,
+	})
+
+	result, err := s.CountContext(ctx)
+	return err == nil && result.Count == 1
+}
+
+// contextError converts a finished context into a generation error.
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrGenerationFailed, solver.ErrTimeout)
+	}
+	return ctx.Err()
 }
 
 // GenerateWithClueCount is a convenience function to generate a puzzle with a specific clue count.
@@ -205,3 +322,9 @@ func GenerateWithClueCount(clueCount int) (*board.Board, *board.Board, error) {
 	gen := New(DefaultOptions(clueCount))
 	return gen.Generate()
 }
+
+// GenerateWithLevel is a convenience function to generate a puzzle of a specific difficulty level.
+func GenerateWithLevel(level rating.Level) (*board.Board, *board.Board, error) {
+	gen := New(LevelOptions(level))
+	return gen.Generate()
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"github.com/rybkr/sudoku/internal/board"
+	"time"
+)
+
+// jigsawSolveTime bounds the search for a solution on a random jigsaw layout, per cell.
+// Some layouts have no solution at all, and are quicker to replace than to rule out.
+const jigsawSolveTime = 2 * time.Millisecond
+
+// jigsawGeometry returns a random jigsaw layout the size of base, with base's extra units and rules.
+// Starting from the boxes of base, neighbouring regions repeatedly trade a pair of cells,
+// and trades that would split a region are undone.
+func (g *Generator) jigsawGeometry(base *board.Geometry) (*board.Geometry, error) {
+	regions := make([]int, base.CellCount())
+	for pos := range regions {
+		regions[pos] = base.BoxOf(pos)
+	}
+
+	for range 4 * base.CellCount() {
+		a := g.rng.Intn(base.CellCount())
+		neighbors := base.Neighbors(a)
+		from, to := regions[a], regions[neighbors[g.rng.Intn(len(neighbors))]]
+		if from == to {
+			continue
+		}
+
+		// Hand a over to the neighbouring region and take back one of its cells bordering ours
+		regions[a] = to
+		var back []int
+		for pos, r := range regions {
+			if r == to && pos != a && borders(base, regions, pos, from) {
+				back = append(back, pos)
+			}
+		}
+		if len(back) == 0 {
+			regions[a] = from
+			continue
+		}
+		b := back[g.rng.Intn(len(back))]
+		regions[b] = from
+
+		if !base.Connected(regions, from) || !base.Connected(regions, to) {
+			regions[a], regions[b] = from, to
+		}
+	}
+
+	geo, err := board.NewJigsawGeometry(regions)
+	if err != nil {
+		return nil, err
+	}
+	if geo, err = geo.WithExtraUnits(base.ExtraUnits()); err != nil {
+		return nil, err
+	}
+	return geo.WithRules(base.Rules())
+}
+
+// borders reports whether pos is next to a cell of region r.
+func borders(geo *board.Geometry, regions []int, pos, r int) bool {
+	for _, n := range geo.Neighbors(pos) {
+		if regions[n] == r {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"cmp"
+	"context"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/solver"
+	"slices"
+)
+
+// MaxCageSize is the most cells in a generated killer cage.
+const MaxCageSize = 5
+
+// addCages partitions the grid into killer cages holding the digits of the solution.
+func (g *Generator) addCages(puzzle, solution *board.Board) error {
+	geo := solution.Geometry()
+	for _, cells := range g.partition(solution) {
+		sum := 0
+		for _, pos := range cells {
+			sum += solution.Get(pos)
+		}
+		c, err := board.NewCage(geo, sum, cells)
+		if err != nil {
+			return err
+		}
+		if err := puzzle.AddConstraint(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settle gives away digits of the solution until the puzzle has a unique solution,
+// up to ClueCount givens, then takes back any givens the rest make redundant.
+func (g *Generator) settle(ctx context.Context, puzzle, solution *board.Board) (*board.Board, error) {
+	geo := solution.Geometry()
+	if !g.options.EnsureUnique {
+		return puzzle, nil
+	}
+
+	for {
+		other, err := g.otherSolution(ctx, puzzle, solution)
+		if err != nil {
+			return nil, err
+		}
+		if other == nil {
+			break
+		}
+		if puzzle.ClueCount() >= g.options.ClueCount {
+			return nil, ErrDiggingFailed
+		}
+
+		// Give away a cell the two solutions disagree on, which rules the other one out
+		var diffs []int
+		for pos := 0; pos < geo.CellCount(); pos++ {
+			if other.Get(pos) != solution.Get(pos) {
+				diffs = append(diffs, pos)
+			}
+		}
+		pos := diffs[g.rng.Intn(len(diffs))]
+		puzzle.SetForce(pos, solution.Get(pos))
+	}
+
+	// Givens added early may have been made redundant by later ones, or by the constraints
+	for _, pos := range g.rng.Perm(geo.CellCount()) {
+		if puzzle.Get(pos) == board.EmptyCell {
+			continue
+		}
+		puzzle.Clear(pos)
+		if !g.hasUniqueSolution(ctx, puzzle) {
+			if ctx.Err() != nil {
+				return nil, contextError(ctx)
+			}
+			puzzle.SetForce(pos, solution.Get(pos))
+		}
+	}
+	if puzzle.ClueCount() > g.options.ClueCount {
+		return nil, ErrDiggingFailed
+	}
+
+	return puzzle, nil
+}
+
+// otherSolution returns a solution of the puzzle other than the given one,
+// or nil if the given solution is unique.
+func (g *Generator) otherSolution(ctx context.Context, puzzle, solution *board.Board) (*board.Board, error) {
+	s := solver.New(puzzle, &solver.Options{
+		Algorithm:    solver.Backtracking,
+		MaxSolutions: 2,
+	})
+
+	var other *board.Board
+	_, err := s.SolveAllContext(ctx, func(b *board.Board) bool {
+		if b.GridString() != solution.GridString() {
+			other = b
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+		return nil, err
+	}
+	return other, nil
+}
+
+// partition splits a solution into random cages of orthogonally connected cells
+// holding distinct digits, of up to MaxCageSize cells each.
+// A cage stops short of its size when no neighbouring cell can join it, so some may be single cells.
+func (g *Generator) partition(solution *board.Board) [][]int {
+	geo := solution.Geometry()
+	maxSize := min(MaxCageSize, geo.Size())
+
+	caged := make([]bool, geo.CellCount())
+	var cages [][]int
+	for _, start := range g.rng.Perm(geo.CellCount()) {
+		if caged[start] {
+			continue
+		}
+		size := 2 + g.rng.Intn(maxSize-1)
+		cells := []int{start}
+		caged[start] = true
+		digits := uint(1) << (solution.Get(start) - 1)
+
+		for len(cells) < size {
+			var next []int
+			for _, pos := range cells {
+				for _, n := range geo.Neighbors(pos) {
+					bit := uint(1) << (solution.Get(n) - 1)
+					if !caged[n] && digits&bit == 0 && !slices.Contains(next, n) {
+						next = append(next, n)
+					}
+				}
+			}
+			if len(next) == 0 {
+				break
+			}
+			pos := next[g.rng.Intn(len(next))]
+			cells = append(cells, pos)
+			caged[pos] = true
+			digits |= uint(1) << (solution.Get(pos) - 1)
+		}
+
+		slices.Sort(cells)
+		cages = append(cages, cells)
+	}
+
+	slices.SortFunc(cages, func(a, b []int) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	return cages
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"context"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/solver"
+)
+
+// GenerateMulti creates a puzzle of several overlapping grids, such as a Samurai.
+// Returns the puzzle and its solution, or an error if generation fails.
+func (g *Generator) GenerateMulti(l *board.Layout) (puzzle *board.Multi, solution *board.Multi, err error) {
+	return g.GenerateMultiContext(context.Background(), l)
+}
+
+// GenerateMultiContext is like GenerateMulti but stops promptly once ctx is done,
+// failing like GenerateContext.
+// Clues are dug from a random solution in random order for as long as it stays unique,
+// which leaves a puzzle with no clue to spare. Only the Timeout and Seed options apply.
+func (g *Generator) GenerateMultiContext(ctx context.Context, l *board.Layout) (puzzle *board.Multi, solution *board.Multi, err error) {
+	if g.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.options.Timeout)
+		defer cancel()
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return nil, nil, contextError(ctx)
+		}
+
+		solution, err = solver.NewMulti(board.NewMulti(l), &solver.Options{
+			MaxSolutions: 1,
+			Randomize:    true,
+			Source:       g.rng,
+		}).SolveContext(ctx)
+		if err != nil {
+			continue
+		}
+
+		puzzle = solution.Clone()
+		for _, i := range g.rng.Perm(l.CellCount()) {
+			cell := l.Cells()[i]
+			val := puzzle.Get(cell)
+			puzzle.Clear(cell)
+			if !g.hasUniqueMultiSolution(ctx, puzzle) {
+				if ctx.Err() != nil {
+					return nil, nil, contextError(ctx)
+				}
+				puzzle.SetForce(cell, val)
+			}
+		}
+
+		return puzzle, solution, nil
+	}
+}
+
+// hasUniqueMultiSolution is like hasUniqueSolution for a multi-grid puzzle.
+func (g *Generator) hasUniqueMultiSolution(ctx context.Context, puzzle *board.Multi) bool {
+	s := solver.NewMulti(puzzle, &solver.Options{
+		MaxSolutions: 2,
+		Randomize:    false,
+	})
+
+	result, err := s.CountContext(ctx)
+	return err == nil && result.Count == 1
+}
@@ -1,18 +1,38 @@
 package generator
 
 import (
+	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/rating"
+	"github.com/rybkr/sudoku/internal/solver"
+	"slices"
 	"time"
 )
 
 // Options configures puzzle generation behavior.
 type Options struct {
-	ClueCount    int           // Number of clues to add to the puzzle
-	Timeout      time.Duration // Timeout limits generation time
-	Seed         int64         // Seed for reproducible puzzles (0 = random)
-	EnsureUnique bool          // EnsureUnique verifies single solution
+	ClueCount    int             // Number of clues to add to the puzzle, or the fewest allowed when Target is set
+	Timeout      time.Duration   // Timeout limits generation time
+	Seed         int64           // Seed for reproducible puzzles (0 = random)
+	EnsureUnique bool            // EnsureUnique verifies single solution
+	Target       *Target         // Target restricts the rated difficulty (nil = any)
+	Symmetry     Symmetry        // Symmetry of the clue pattern
+	Geometry     *board.Geometry // Geometry of the grid (nil = standard 9x9)
+	Killer       bool            // Killer cages the solution, with ClueCount as the most givens; Target and Symmetry are ignored
+	Jigsaw       bool            // Jigsaw generates each puzzle on a new random region layout of Geometry's size
+	Clues        ClueKinds       // Clues adds variant clues of these kinds, with ClueCount as the most givens; Target and Symmetry are ignored
+	Rate         bool            // Rate has GenerateBatch rate every puzzle, not only those dug to a Target
 }
 
-// DefaultOptions returns standard generator options.
+// Target describes which rated puzzles are acceptable.
+type Target struct {
+	MinLevel  rating.Level       // Easiest acceptable level
+	MaxLevel  rating.Level       // Hardest acceptable level
+	Required  []solver.Technique // Techniques the logical solve path must use
+	Forbidden []solver.Technique // Techniques the logical solve path must not use
+}
+
+// DefaultOptions returns standard generator options for 9x9 puzzles.
 func DefaultOptions(clueCount int) *Options {
 	clueCount = min(max(clueCount, MinValidClueCount), MaxValidClueCount)
 	return &Options{
@@ -22,3 +42,73 @@ func DefaultOptions(clueCount int) *Options {
 		EnsureUnique: true,
 	}
 }
+
+// Validate checks that puzzles can be generated with the options, so that callers generating
+// many puzzles can fail once up front. GenerateContext checks them again on every call.
+func (o *Options) Validate() error {
+	geo := o.geometry()
+	lo, hi := ClueCountRange(geo)
+	switch {
+	case o.Killer:
+		lo = 0
+	case o.Clues != 0:
+		// Variant clues are added to a dug puzzle, which keeps at least one given
+		lo = 1
+	}
+	if o.ClueCount < lo || o.ClueCount > hi {
+		return fmt.Errorf("%w: %d clues on %s, want %d-%d", ErrInvalidClueCount, o.ClueCount, geo, lo, hi)
+	}
+	if o.Target == nil && !o.Killer && o.Clues == 0 {
+		// Symmetric groups come out whole, so only some clue counts can be dug to
+		if counts := o.Symmetry.clueCounts(geo); !counts[o.ClueCount] {
+			return fmt.Errorf("%w: %d clues cannot keep %s symmetry on %s, try %s",
+				ErrInvalidClueCount, o.ClueCount, o.Symmetry, geo, nearestClueCounts(counts, o.ClueCount, lo, hi))
+		}
+	}
+	if extras := geo.ExtraUnits() &^ board.Diagonals; o.Jigsaw && extras != 0 {
+		return fmt.Errorf("%w: jigsaw puzzles cannot have %s", ErrGenerationFailed, extras)
+	}
+	return nil
+}
+
+// geometry returns the grid geometry of generated puzzles.
+func (o *Options) geometry() *board.Geometry {
+	if o.Geometry == nil {
+		return board.Standard
+	}
+	return o.Geometry
+}
+
+// LevelOptions returns generator options targeting a single difficulty level.
+// Clues are dug as far as the target allows.
+func LevelOptions(level rating.Level) *Options {
+	opts := DefaultOptions(MinValidClueCount)
+	opts.Target = &Target{MinLevel: level, MaxLevel: level}
+	return opts
+}
+
+// Accepts reports whether a rating satisfies the target.
+func (t *Target) Accepts(r *rating.Rating) bool {
+	if r.Level < t.MinLevel || r.Level > t.MaxLevel {
+		return false
+	}
+	for _, tech := range t.Required {
+		if !r.Uses(tech) {
+			return false
+		}
+	}
+	return !t.tooHard(r)
+}
+
+// tooHard reports whether a rating exceeds the target in a way that digging further cannot fix.
+func (t *Target) tooHard(r *rating.Rating) bool {
+	if r.Level > t.MaxLevel {
+		return true
+	}
+	return slices.ContainsFunc(t.Forbidden, r.Uses)
+}
+
+// bounded reports whether the target caps difficulty, which requires rating while digging.
+func (t *Target) bounded() bool {
+	return t.MaxLevel < rating.Diabolical || len(t.Forbidden) > 0
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rybkr/sudoku/internal/board"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownSymmetry = errors.New("unknown symmetry")
+)
+
+// Symmetry describes which cells are dug together so the clue pattern stays symmetric.
+type Symmetry int
+
+const (
+	SymmetryNone          Symmetry = iota // Cells are dug independently
+	SymmetryRotational180                 // Pattern is unchanged by a half turn
+	SymmetryRotational90                  // Pattern is unchanged by a quarter turn
+	SymmetryHorizontal                    // Pattern mirrors across the middle row
+	SymmetryVertical                      // Pattern mirrors across the middle column
+	SymmetryDiagonal                      // Pattern mirrors across the main diagonal
+	SymmetryAntiDiagonal                  // Pattern mirrors across the anti-diagonal
+)
+
+var symmetryNames = [...]string{
+	SymmetryNone:          "none",
+	SymmetryRotational180: "rotational",
+	SymmetryRotational90:  "rotational90",
+	SymmetryHorizontal:    "horizontal",
+	SymmetryVertical:      "vertical",
+	SymmetryDiagonal:      "diagonal",
+	SymmetryAntiDiagonal:  "antidiagonal",
+}
+
+// String returns the name of the symmetry as accepted by ParseSymmetry.
+func (s Symmetry) String() string {
+	if s < SymmetryNone || s > SymmetryAntiDiagonal {
+		return fmt.Sprintf("Symmetry(%d)", int(s))
+	}
+	return symmetryNames[s]
+}
+
+// ParseSymmetry converts a case-insensitive symmetry name into a Symmetry.
+func ParseSymmetry(s string) (Symmetry, error) {
+	for sym, name := range symmetryNames {
+		if strings.EqualFold(s, name) {
+			return Symmetry(sym), nil
+		}
+	}
+	return SymmetryNone, fmt.Errorf("%w: %q", ErrUnknownSymmetry, s)
+}
+
+// images returns the cells a symmetry maps (row, col) onto, including itself.
+func (s Symmetry) images(g *board.Geometry, row, col int) []int {
+	n := g.Size() - 1
+	switch s {
+	case SymmetryRotational180:
+		return []int{g.MakePos(row, col), g.MakePos(n-row, n-col)}
+	case SymmetryRotational90:
+		return []int{
+			g.MakePos(row, col), g.MakePos(col, n-row),
+			g.MakePos(n-row, n-col), g.MakePos(n-col, row),
+		}
+	case SymmetryHorizontal:
+		return []int{g.MakePos(row, col), g.MakePos(n-row, col)}
+	case SymmetryVertical:
+		return []int{g.MakePos(row, col), g.MakePos(row, n-col)}
+	case SymmetryDiagonal:
+		return []int{g.MakePos(row, col), g.MakePos(col, row)}
+	case SymmetryAntiDiagonal:
+		return []int{g.MakePos(row, col), g.MakePos(n-col, n-row)}
+	default:
+		return []int{g.MakePos(row, col)}
+	}
+}
+
+// orbits partitions the grid into groups of cells that map onto each other.
+func (s Symmetry) orbits(g *board.Geometry) [][]int {
+	var orbits [][]int
+	seen := make([]bool, g.CellCount())
+
+	for pos := 0; pos < g.CellCount(); pos++ {
+		if seen[pos] {
+			continue
+		}
+
+		var orbit []int
+		for _, img := range s.images(g, g.RowOf(pos), g.ColOf(pos)) {
+			if !seen[img] {
+				seen[img] = true
+				orbit = append(orbit, img)
+			}
+		}
+		orbits = append(orbits, orbit)
+	}
+
+	return orbits
+}
+
+// clueCounts reports, for each clue count from 0 to every cell, whether digging whole orbits
+// from a complete grid can leave exactly that many clues.
+func (s Symmetry) clueCounts(g *board.Geometry) []bool {
+	counts := make([]bool, g.CellCount()+1)
+	counts[g.CellCount()] = true
+	for _, orbit := range s.orbits(g) {
+		// Counts below n are set before n is read, so each orbit is dug at most once
+		for n := len(orbit); n < len(counts); n++ {
+			if counts[n] {
+				counts[n-len(orbit)] = true
+			}
+		}
+	}
+	return counts
+}
+
+// ClueCountAtLeast returns the fewest clues, no fewer than n, that digging cells together
+// under the symmetry can leave on grids of g.
+func (s Symmetry) ClueCountAtLeast(g *board.Geometry, n int) int {
+	counts := s.clueCounts(g)
+	for n < g.CellCount() && !counts[n] {
+		n++
+	}
+	return n
+}
+
+// nearestClueCounts names the closest counts below and above n that counts allows
+// within lo to hi, e.g. "29 or 32".
+func nearestClueCounts(counts []bool, n, lo, hi int) string {
+	var near []string
+	for i := n - 1; i >= lo; i-- {
+		if counts[i] {
+			near = append(near, strconv.Itoa(i))
+			break
+		}
+	}
+	for i := n + 1; i <= hi; i++ {
+		if counts[i] {
+			near = append(near, strconv.Itoa(i))
+			break
+		}
+	}
+	return strings.Join(near, " or ")
+}
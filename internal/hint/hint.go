@@ -0,0 +1,138 @@
+package hint
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/solver"
+)
+
+var (
+	ErrSolved       = errors.New("board is already solved")
+	ErrUnknownLevel = errors.New("unknown hint level")
+)
+
+// Level controls how much of a hint is revealed.
+// Each level includes everything revealed by the levels before it.
+type Level int
+
+const (
+	LevelArea      Level = iota // Which cells or units to look at
+	LevelTechnique              // Which technique applies there
+	LevelDigit                  // Which digits the technique works on
+	LevelAnswer                 // The complete step and its result
+)
+
+var levelNames = [...]string{
+	LevelArea:      "area",
+	LevelTechnique: "technique",
+	LevelDigit:     "digit",
+	LevelAnswer:    "answer",
+}
+
+// String returns the name of the level as accepted by ParseLevel.
+func (l Level) String() string {
+	if l < LevelArea || l > LevelAnswer {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel converts a case-insensitive level name into a Level.
+func ParseLevel(s string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(l), nil
+		}
+	}
+	return LevelArea, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+}
+
+// Hint is the easiest logical step available on a board.
+type Hint struct {
+	Step solver.Step
+}
+
+// Next finds the easiest next logical step on a partially filled board.
+// The board's pencil marks are trusted, so eliminations already made are not hinted again.
+// Returns solver.ErrNoSolution if the board has reached a contradiction,
+// and solver.ErrStuck if no technique applies.
+func Next(b *board.Board) (*Hint, error) {
+	if !b.IsValid() {
+		return nil, solver.ErrInvalidPuzzle
+	}
+	if b.EmptyCount() == 0 {
+		return nil, ErrSolved
+	}
+
+	step, err := solver.NewLogical(b).Find()
+	if err != nil {
+		return nil, err
+	}
+	if step == nil {
+		return nil, solver.ErrStuck
+	}
+	return &Hint{Step: *step}, nil
+}
+
+// Reveal describes the hint, disclosing no more than the given level.
+func (h *Hint) Reveal(level Level) string {
+	switch {
+	case level <= LevelArea:
+		return fmt.Sprintf("Look at %s.", h.Area())
+	case level == LevelTechnique:
+		return fmt.Sprintf("Look at %s: there is a %s.", h.Area(), h.Step.Technique)
+	case level == LevelDigit:
+		return fmt.Sprintf("Look at %s: there is a %s on %s.", h.Area(), h.Step.Technique, h.digits())
+	default:
+		return h.Step.String()
+	}
+}
+
+// Progression returns the hint at every level, from vaguest to complete.
+func (h *Hint) Progression() []string {
+	var reveals []string
+	for l := LevelArea; l <= LevelAnswer; l++ {
+		reveals = append(reveals, h.Reveal(l))
+	}
+	return reveals
+}
+
+// Area names the region to look at: the units the step lives in,
+// or its cells when the pattern spans several units.
+func (h *Hint) Area() string {
+	var names []string
+	if len(h.Step.Units) > 0 {
+		for _, u := range h.Step.Units {
+			names = append(names, u.String())
+		}
+	} else {
+		g := h.Step.Geometry
+		if g == nil {
+			g = board.Standard
+		}
+		for _, pos := range h.Step.Cells {
+			names = append(names, g.CellName(pos))
+		}
+	}
+	return joinList(names)
+}
+
+// digits lists the digits of the step in prose, e.g. "3 and 7".
+func (h *Hint) digits() string {
+	names := make([]string, len(h.Step.Digits))
+	for i, d := range h.Step.Digits {
+		names[i] = fmt.Sprint(d)
+	}
+	return joinList(names)
+}
+
+// joinList joins items as "a", "a and b" or "a, b and c".
+func joinList(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
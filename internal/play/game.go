@@ -0,0 +1,333 @@
+package play
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/hint"
+	"github.com/rybkr/sudoku/internal/solver"
+)
+
+// MaxSize is the largest grid that can be played.
+const MaxSize = 9
+
+var (
+	ErrTooLarge = errors.New("grid is too large to play")
+)
+
+// Game is a puzzle being played: the player's board, whose marks are their pencil marks,
+// the cursor and the move history.
+type Game struct {
+	puzzle   *board.Board
+	solution *board.Board // nil if unknown, in which case checks count solutions
+	board    *board.Board
+	geo      *board.Geometry
+
+	cursor int
+	pencil bool // pencil reports whether digits toggle pencil marks instead of being placed
+
+	undo []move
+	redo []move
+
+	hint      *hint.Hint
+	hintLevel hint.Level
+	mistakes  []int
+	message   string
+
+	start    time.Time
+	finished time.Duration // Solve time, zero while still playing
+}
+
+// cellState is the contents of one cell.
+type cellState struct {
+	val   int
+	marks uint // Stored marks of the board, every digit when the cell has no notes
+}
+
+// move is a change to a single cell that can be undone.
+type move struct {
+	pos    int
+	before cellState
+	after  cellState
+}
+
+// New starts a game on a puzzle. The solution may be nil.
+// Digits are entered with a single key, so grids larger than 9x9 are not supported.
+func New(puzzle, solution *board.Board) (*Game, error) {
+	if puzzle.Size() > MaxSize {
+		return nil, fmt.Errorf("%w: %s", ErrTooLarge, puzzle.Geometry())
+	}
+
+	g := &Game{
+		puzzle:   puzzle.Clone(),
+		solution: solution,
+		board:    puzzle.Clone(),
+		geo:      puzzle.Geometry(),
+		start:    time.Now(),
+	}
+	for pos := 0; pos < puzzle.CellCount(); pos++ {
+		if !g.Given(pos) {
+			g.cursor = pos
+			break
+		}
+	}
+	return g, nil
+}
+
+// Board returns the player's current board.
+func (g *Game) Board() *board.Board {
+	return g.board
+}
+
+// Cursor returns the position of the cursor.
+func (g *Game) Cursor() int {
+	return g.cursor
+}
+
+// Given reports whether a cell was filled in the puzzle.
+func (g *Game) Given(pos int) bool {
+	return g.puzzle.Get(pos) != board.EmptyCell
+}
+
+// Notes returns the pencil marks of a cell, or 0 if it has none.
+// The board keeps every digit as a candidate of a cell without notes,
+// so a cell noted with every digit has no notes either.
+func (g *Game) Notes(pos int) uint {
+	if marks := g.board.Marks(pos); marks != g.geo.AllDigits() {
+		return marks
+	}
+	return 0
+}
+
+// Pencil reports whether digits currently toggle pencil marks.
+func (g *Game) Pencil() bool {
+	return g.pencil
+}
+
+// Mistake reports whether the last check found a cell to be wrong.
+func (g *Game) Mistake(pos int) bool {
+	for _, m := range g.mistakes {
+		if m == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// Message returns the feedback for the player's last action.
+func (g *Game) Message() string {
+	return g.message
+}
+
+// Solved reports whether the board has been completed correctly.
+func (g *Game) Solved() bool {
+	return g.finished > 0
+}
+
+// Elapsed returns the time spent on the puzzle, frozen once it is solved.
+func (g *Game) Elapsed() time.Duration {
+	if g.Solved() {
+		return g.finished
+	}
+	return time.Since(g.start)
+}
+
+// Move shifts the cursor, wrapping around the edges of the grid.
+func (g *Game) Move(dRow, dCol int) {
+	n := g.geo.Size()
+	row := (g.geo.RowOf(g.cursor) + dRow + n) % n
+	col := (g.geo.ColOf(g.cursor) + dCol + n) % n
+	g.cursor = g.geo.MakePos(row, col)
+}
+
+// TogglePencil switches between placing digits and toggling pencil marks.
+func (g *Game) TogglePencil() {
+	g.pencil = !g.pencil
+	if g.pencil {
+		g.message = "Pencil mode: digits toggle notes."
+	} else {
+		g.message = "Digit mode: digits fill the cell."
+	}
+}
+
+// Enter places a digit under the cursor, or toggles its pencil mark in pencil mode.
+func (g *Game) Enter(val int) {
+	if !g.editable() || val > g.geo.Size() {
+		return
+	}
+	pos := g.cursor
+	state := g.state(pos)
+
+	if g.pencil {
+		if state.val != board.EmptyCell {
+			g.message = "Erase the digit before adding notes."
+			return
+		}
+		state.marks = g.marksFor(g.Notes(pos) ^ 1<<(val-1))
+		g.play(pos, state)
+		return
+	}
+
+	if state.val == val {
+		return
+	}
+	for _, peer := range g.geo.Peers(pos) {
+		if g.board.Get(peer) == val {
+			g.message = fmt.Sprintf("%d is already in %s.", val, g.geo.CellName(peer))
+			return
+		}
+	}
+	state.val = val
+	g.play(pos, state)
+}
+
+// Erase clears the digit under the cursor, or its pencil marks if it has no digit.
+func (g *Game) Erase() {
+	if !g.editable() {
+		return
+	}
+	state := g.state(g.cursor)
+	if state.val != board.EmptyCell {
+		state.val = board.EmptyCell
+	} else {
+		state.marks = g.geo.AllDigits()
+	}
+	g.play(g.cursor, state)
+}
+
+// Undo reverts the last move. A solved puzzle stays solved.
+func (g *Game) Undo() {
+	if g.Solved() {
+		return
+	}
+	if len(g.undo) == 0 {
+		g.message = "Nothing to undo."
+		return
+	}
+	m := g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	g.redo = append(g.redo, m)
+	g.message = ""
+	g.apply(m.pos, m.before)
+	g.cursor = m.pos
+}
+
+// Redo replays the last undone move. A solved puzzle stays solved.
+func (g *Game) Redo() {
+	if g.Solved() {
+		return
+	}
+	if len(g.redo) == 0 {
+		g.message = "Nothing to redo."
+		return
+	}
+	m := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	g.undo = append(g.undo, m)
+	g.message = ""
+	g.apply(m.pos, m.after)
+	g.cursor = m.pos
+}
+
+// Hint shows the next logical step. Asking again without changing the board
+// reveals more of the same hint, up to the full answer.
+func (g *Game) Hint() {
+	if g.Solved() {
+		return
+	}
+	if g.hint != nil {
+		if g.hintLevel < hint.LevelAnswer {
+			g.hintLevel++
+		}
+		g.message = g.hint.Reveal(g.hintLevel)
+		return
+	}
+
+	h, err := hint.Next(g.board)
+	switch {
+	case errors.Is(err, solver.ErrStuck):
+		g.message = "No logical step found. Check for mistakes?"
+	case errors.Is(err, solver.ErrNoSolution):
+		g.message = "The board has reached a contradiction. Check for mistakes."
+	case err != nil:
+		g.message = err.Error()
+	default:
+		g.hint, g.hintLevel = h, hint.LevelArea
+		g.message = h.Reveal(g.hintLevel)
+	}
+}
+
+// Check marks every filled cell that disagrees with the solution.
+func (g *Game) Check() {
+	r, err := solver.Check(g.puzzle, g.board, g.solution)
+	if err != nil {
+		g.message = fmt.Sprintf("Cannot check: %v.", err)
+		return
+	}
+
+	g.mistakes = r.Mistakes
+	switch n := len(r.Mistakes); {
+	case n == 1:
+		g.message = "1 mistake."
+	case n > 1:
+		g.message = fmt.Sprintf("%d mistakes.", n)
+	case r.DeadEnd():
+		g.message = "No mistakes in sight, but the board can no longer be solved."
+	default:
+		g.message = "No mistakes so far."
+	}
+}
+
+// editable reports whether the cell under the cursor may be changed,
+// explaining why not otherwise.
+func (g *Game) editable() bool {
+	switch {
+	case g.Solved():
+		return false
+	case g.Given(g.cursor):
+		g.message = "That cell is a given."
+		return false
+	}
+	return true
+}
+
+// state returns the contents of a cell.
+func (g *Game) state(pos int) cellState {
+	return cellState{val: g.board.Get(pos), marks: g.board.Marks(pos)}
+}
+
+// marksFor returns the stored marks of a cell with the given notes.
+func (g *Game) marksFor(notes uint) uint {
+	if notes == 0 {
+		return g.geo.AllDigits()
+	}
+	return notes
+}
+
+// play makes a new move, discarding any moves that were undone.
+func (g *Game) play(pos int, after cellState) {
+	g.undo = append(g.undo, move{pos: pos, before: g.state(pos), after: after})
+	g.redo = g.redo[:0]
+	g.message = ""
+	g.apply(pos, after)
+}
+
+// apply sets the contents of a cell and invalidates anything derived from the old board.
+func (g *Game) apply(pos int, s cellState) {
+	g.board.Clear(pos)
+	if s.val != board.EmptyCell {
+		g.board.SetForce(pos, s.val)
+	}
+	g.board.SetMarks(pos, s.marks)
+	g.hint = nil
+	g.mistakes = nil
+
+	if g.board.EmptyCount() == 0 {
+		if r, err := solver.Check(g.puzzle, g.board, g.solution); err == nil && r.Solvable {
+			g.finished = time.Since(g.start)
+			g.message = fmt.Sprintf("Solved in %s!", formatDuration(g.finished))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package play
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// ANSI escape sequences used to style the grid
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleRed     = "\x1b[31m"
+)
+
+// helpText lists the key bindings below the grid.
+const helpText = `arrows/hjkl move   1-9 enter   0/x/backspace erase   p pencil
+u undo   r redo   ? hint   c check   q quit`
+
+// Render draws the game as text, laid out like Board.Format
+// with the cursor cell highlighted.
+func (g *Game) Render() string {
+	var sb strings.Builder
+
+	geo := g.geo
+	title := "Sudoku"
+	var variants []string
+	if geo.ExtraUnits() != 0 {
+		variants = append(variants, geo.ExtraUnits().String())
+	}
+	if geo.Rules() != 0 {
+		variants = append(variants, geo.Rules().String())
+	}
+	if len(variants) > 0 {
+		title += " (" + strings.Join(variants, ",") + ")"
+	}
+	if g.Solved() {
+		title += " - solved"
+	}
+	grid := geo.Draw(g.renderCell)
+	width, _, _ := strings.Cut(grid, "\n")
+	fmt.Fprintf(&sb, "%-*s%6s\n", len(width)-6, title, formatDuration(g.Elapsed()))
+	sb.WriteString(grid)
+
+	mode := "digits"
+	if g.pencil {
+		mode = "pencil"
+	}
+	fmt.Fprintf(&sb, "%s  Notes: %-17s Mode: %s\n", g.geo.CellName(g.cursor), noteList(g.Notes(g.cursor)), mode)
+	for _, c := range g.board.Constraints() {
+		if slices.Contains(c.Cells(), g.cursor) {
+			fmt.Fprintf(&sb, "%s\n", c)
+		}
+	}
+	fmt.Fprintf(&sb, "%s\n\n", g.message)
+	sb.WriteString(helpText)
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// renderCell draws a single cell: givens in bold, mistakes in red,
+// and empty cells with pencil marks as a dim '*'.
+func (g *Game) renderCell(pos int) string {
+	var style string
+	if pos == g.cursor {
+		style += styleReverse
+	}
+
+	ch := byte('.')
+	switch val := g.board.Get(pos); {
+	case val != board.EmptyCell:
+		ch = '0' + byte(val)
+		if g.Given(pos) {
+			style += styleBold
+		} else if g.Mistake(pos) {
+			style += styleRed
+		}
+	case g.Notes(pos) != 0:
+		ch = '*'
+		style += styleDim
+	}
+
+	if style == "" {
+		return string(ch)
+	}
+	return style + string(ch) + styleReset
+}
+
+// noteList formats a pencil mark bitmask as "1 4 7", or "-" when empty.
+func noteList(mask uint) string {
+	var digits []string
+	for val := 1; val <= 9; val++ {
+		if mask&(1<<(val-1)) != 0 {
+			digits = append(digits, fmt.Sprint(val))
+		}
+	}
+	if len(digits) == 0 {
+		return "-"
+	}
+	return strings.Join(digits, " ")
+}
+
+// formatDuration formats a duration as m:ss, or h:mm:ss past an hour.
+func formatDuration(d time.Duration) string {
+	s := int(d / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
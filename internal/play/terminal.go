@@ -0,0 +1,187 @@
+package play
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotTerminal = errors.New("play needs an interactive terminal")
+)
+
+// Terminal control sequences
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // Switch to the alternate screen and hide the cursor
+	leaveScreen = "\x1b[?25h\x1b[?1049l" // Show the cursor and return to the main screen
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+// key is a keypress: a printable rune, a control character, or one of the arrow keys below.
+type key rune
+
+const (
+	keyUp key = -(iota + 1)
+	keyDown
+	keyLeft
+	keyRight
+)
+
+// Control characters, as sent by a terminal in raw mode
+const (
+	keyCtrlC     key = 3
+	keyBackspace key = 8
+	keyCtrlR     key = 18
+	keyEscape    key = 27
+	keyDelete    key = 127
+)
+
+// Run plays a game full-screen on the terminal until the player quits.
+// tty must be an interactive terminal; its mode is restored before returning.
+func Run(g *Game, tty *os.File) error {
+	restore, err := makeRaw(tty)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	fmt.Fprint(tty, enterScreen)
+	defer fmt.Fprint(tty, leaveScreen)
+
+	// The reader stays blocked on tty after Run returns, which is harmless at exit
+	keys := make(chan []key)
+	errs := make(chan error, 1)
+	go readKeys(tty, keys, errs)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		draw(tty, g)
+		select {
+		case batch := <-keys:
+			for _, k := range batch {
+				if !handle(g, k) {
+					return nil
+				}
+			}
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// handle applies a keypress to the game, returning false once the player quits.
+func handle(g *Game, k key) bool {
+	switch k {
+	case 'q', 'Q', keyCtrlC:
+		return false
+	case keyUp, 'k', 'w':
+		g.Move(-1, 0)
+	case keyDown, 'j', 's':
+		g.Move(1, 0)
+	case keyLeft, 'h', 'a':
+		g.Move(0, -1)
+	case keyRight, 'l', 'd':
+		g.Move(0, 1)
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		g.Enter(int(k - '0'))
+	case '0', '.', ' ', 'x', keyBackspace, keyDelete:
+		g.Erase()
+	case 'p', 'n':
+		g.TogglePencil()
+	case 'u', 'z':
+		g.Undo()
+	case 'r', keyCtrlR:
+		g.Redo()
+	case '?':
+		g.Hint()
+	case 'c':
+		g.Check()
+	}
+	return true
+}
+
+// draw redraws the whole screen. Raw mode turns off newline translation,
+// so every line ends in an explicit carriage return.
+func draw(w io.Writer, g *Game) {
+	screen := strings.ReplaceAll(g.Render(), "\n", "\r\n")
+	fmt.Fprint(w, clearScreen+screen)
+}
+
+// readKeys decodes keypresses from r and sends them in the batches they arrive in.
+func readKeys(r io.Reader, keys chan<- []key, errs chan<- error) {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			keys <- decodeKeys(buf[:n])
+		}
+		if err != nil {
+			errs <- err
+			return
+		}
+	}
+}
+
+// decodeKeys splits raw terminal input into keys, turning arrow key
+// escape sequences (ESC [ A through ESC [ D, or ESC O A in application mode) into arrow keys.
+func decodeKeys(in []byte) []key {
+	var keys []key
+	for i := 0; i < len(in); i++ {
+		if in[i] == byte(keyEscape) && i+2 < len(in) && (in[i+1] == '[' || in[i+1] == 'O') {
+			if arrow, ok := arrows[in[i+2]]; ok {
+				keys = append(keys, arrow)
+				i += 2
+				continue
+			}
+		}
+		keys = append(keys, key(in[i]))
+	}
+	return keys
+}
+
+// arrows maps the final byte of an arrow key escape sequence to its key.
+var arrows = map[byte]key{
+	'A': keyUp,
+	'B': keyDown,
+	'C': keyRight,
+	'D': keyLeft,
+}
+
+// makeRaw switches the terminal to raw mode, so keys arrive unbuffered and unechoed,
+// and returns a function restoring the previous mode. It drives stty rather than
+// issuing ioctls itself, which keeps it portable across Unix systems.
+func makeRaw(tty *os.File) (func(), error) {
+	if info, err := tty.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, ErrNotTerminal
+	}
+
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotTerminal, err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotTerminal, err)
+	}
+
+	return func() {
+		stty(tty, strings.TrimSpace(saved))
+	}, nil
+}
+
+// stty runs stty on the terminal with the given arguments and returns its output.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
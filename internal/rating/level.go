@@ -0,0 +1,71 @@
+package rating
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownLevel = errors.New("unknown difficulty level")
+)
+
+// Level is a named difficulty band.
+type Level int
+
+const (
+	Easy Level = iota
+	Medium
+	Hard
+	Expert
+	Diabolical
+)
+
+var levelNames = [...]string{
+	Easy:       "Easy",
+	Medium:     "Medium",
+	Hard:       "Hard",
+	Expert:     "Expert",
+	Diabolical: "Diabolical",
+}
+
+// Upper score bound of each level, inclusive. Diabolical has no bound.
+var levelCeilings = [...]float64{
+	Easy:   2.5,
+	Medium: 3.5,
+	Hard:   4.5,
+	Expert: 6.0,
+}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	if l < Easy || l > Diabolical {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Levels returns every level from easiest to hardest.
+func Levels() []Level {
+	return []Level{Easy, Medium, Hard, Expert, Diabolical}
+}
+
+// ParseLevel converts a case-insensitive level name into a Level.
+func ParseLevel(s string) (Level, error) {
+	for _, l := range Levels() {
+		if strings.EqualFold(s, l.String()) {
+			return l, nil
+		}
+	}
+	return Easy, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+}
+
+// LevelFor maps a score to its difficulty level.
+func LevelFor(score float64) Level {
+	for l, ceiling := range levelCeilings {
+		if score <= ceiling {
+			return Level(l)
+		}
+	}
+	return Diabolical
+}
@@ -0,0 +1,99 @@
+package rating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rybkr/sudoku/internal/board"
+	"github.com/rybkr/sudoku/internal/solver"
+)
+
+// GuessScore is the score of a puzzle that logic alone cannot finish.
+const GuessScore = 7.0
+
+// weights scores each technique in the style of Sudoku Explainer ratings.
+var weights = map[solver.Technique]float64{
+	solver.HiddenSingle:       1.5,
+	solver.NakedSingle:        2.3,
+	solver.PointingCandidates: 2.6,
+	solver.ClaimingCandidates: 2.8,
+	solver.NakedPair:          3.0,
+	solver.HiddenPair:         3.4,
+	solver.NakedTriple:        3.6,
+	solver.HiddenTriple:       4.0,
+	solver.NakedQuad:          4.2,
+	solver.HiddenQuad:         4.4,
+	solver.XWing:              4.6,
+	solver.Swordfish:          4.8,
+	solver.Jellyfish:          5.0,
+	solver.XYWing:             5.2,
+	solver.XYZWing:            5.4,
+	solver.WWing:              5.6,
+	solver.SimpleColoring:     5.8,
+	solver.UniqueRectangle:    6.0,
+}
+
+// Weight returns the score of a single technique.
+func Weight(t solver.Technique) float64 {
+	return weights[t]
+}
+
+// Rating describes how hard a puzzle is to solve by logic alone.
+type Rating struct {
+	Score   float64                  // Weight of the hardest technique needed, or GuessScore
+	Effort  float64                  // Sum of technique weights over every step taken
+	Level   Level                    // Difficulty band the score falls in
+	Hardest solver.Technique         // Hardest technique applied
+	Counts  map[solver.Technique]int // Number of times each technique was applied
+	Steps   []solver.Step            // Steps taken, in order
+	Solved  bool                     // Solved reports whether logic alone finished the puzzle
+}
+
+// Rate solves a puzzle using logic only and scores it by the hardest technique needed.
+// Puzzles that need guessing are rated Diabolical.
+// Rate assumes the puzzle has a unique solution, as Unique Rectangles rely on it.
+func Rate(b *board.Board) (*Rating, error) {
+	return RateContext(context.Background(), b)
+}
+
+// RateContext is like Rate but gives up once ctx is done,
+// returning solver.ErrTimeout or ctx.Err().
+func RateContext(ctx context.Context, b *board.Board) (*Rating, error) {
+	ls := solver.NewLogical(b)
+	steps, err := ls.SolveContext(ctx)
+	if err != nil && !errors.Is(err, solver.ErrStuck) {
+		return nil, err
+	}
+
+	r := &Rating{
+		Counts: make(map[solver.Technique]int),
+		Steps:  steps,
+		Solved: err == nil,
+	}
+	for _, step := range steps {
+		w := Weight(step.Technique)
+		r.Counts[step.Technique]++
+		r.Effort += w
+		if w > r.Score {
+			r.Score = w
+			r.Hardest = step.Technique
+		}
+	}
+	if !r.Solved {
+		r.Score = GuessScore
+	}
+	r.Level = LevelFor(r.Score)
+
+	return r, nil
+}
+
+// Uses reports whether the rating's solve path applied a technique.
+func (r *Rating) Uses(t solver.Technique) bool {
+	return r.Counts[t] > 0
+}
+
+// String returns the level and score, e.g. "Hard (3.6)".
+func (r *Rating) String() string {
+	return fmt.Sprintf("%s (%.1f)", r.Level, r.Score)
+}
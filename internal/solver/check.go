@@ -0,0 +1,90 @@
+package solver
+
+import (
+	"context"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// Report describes the mistakes on a player's board.
+type Report struct {
+	Mistakes  []int // Filled cells whose value differs from the solution, in board order
+	Conflicts bool  // Conflicts reports whether two filled cells in a unit share a digit
+	Solvable  bool  // Solvable reports whether the board can still be completed
+}
+
+// DeadEnd reports whether the board can no longer be completed
+// even though no two filled cells visibly conflict.
+func (r *Report) DeadEnd() bool {
+	return !r.Solvable && !r.Conflicts
+}
+
+// Check compares a player's current board with the puzzle's solution.
+// If solution is nil it is recovered from puzzle by counting, which requires
+// the puzzle to be unique: ErrMultipleSolutions or ErrNoSolution are returned otherwise.
+// Pencil marks are ignored; only filled cells can be mistakes.
+func Check(puzzle, current, solution *board.Board) (*Report, error) {
+	return CheckContext(context.Background(), puzzle, current, solution)
+}
+
+// CheckContext is like Check but gives up once ctx is done,
+// returning ErrTimeout or ctx.Err().
+func CheckContext(ctx context.Context, puzzle, current, solution *board.Board) (*Report, error) {
+	if solution == nil {
+		var err error
+		if solution, err = uniqueSolution(ctx, puzzle); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Report{Conflicts: !current.IsValid()}
+	for pos := 0; pos < current.CellCount(); pos++ {
+		if val := current.Get(pos); val != board.EmptyCell && val != solution.Get(pos) {
+			r.Mistakes = append(r.Mistakes, pos)
+		}
+	}
+
+	switch {
+	case r.Conflicts:
+		r.Solvable = false
+	case len(r.Mistakes) == 0:
+		r.Solvable = true
+	default:
+		// A mistake need not be fatal when the board has strayed from the puzzle's givens
+		s := New(withoutMarks(current), &Options{Algorithm: DancingLinks, MaxSolutions: 1})
+		result, err := s.CountContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r.Solvable = result.Count > 0
+	}
+
+	return r, nil
+}
+
+// uniqueSolution solves a puzzle, requiring exactly one solution.
+func uniqueSolution(ctx context.Context, puzzle *board.Board) (*board.Board, error) {
+	var solution *board.Board
+	s := New(withoutMarks(puzzle), &Options{Algorithm: DancingLinks, MaxSolutions: 2})
+	result, err := s.SolveAllContext(ctx, func(b *board.Board) bool {
+		solution = b
+		return true
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case result.Count == 0:
+		return nil, ErrNoSolution
+	case result.Count > 1:
+		return nil, ErrMultipleSolutions
+	}
+	return solution, nil
+}
+
+// withoutMarks returns a copy of b with every pencil mark restored,
+// so that the player's eliminations cannot hide solutions.
+func withoutMarks(b *board.Board) *board.Board {
+	clone := b.Clone()
+	clone.ResetCandidates()
+	return clone
+}
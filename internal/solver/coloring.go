@@ -0,0 +1,105 @@
+package solver
+
+import (
+	"slices"
+)
+
+// findSimpleColoring follows chains of conjugate pairs (units where a digit has exactly two places),
+// alternately coloring their cells. Exactly one color holds the digit, so:
+//   - if two cells of the same color see each other, that color is false (color wrap);
+//   - any other cell seeing both colors cannot hold the digit (color trap).
+func findSimpleColoring(ls *LogicalSolver) *Step {
+	for val := 1; val <= ls.Board.Size(); val++ {
+		links := make(map[int][]int)
+		for _, u := range ls.geo().Units() {
+			if cells := ls.cellsWith(u, val); len(cells) == 2 {
+				links[cells[0]] = append(links[cells[0]], cells[1])
+				links[cells[1]] = append(links[cells[1]], cells[0])
+			}
+		}
+
+		color := make(map[int]int)
+		for start := 0; start < ls.Board.CellCount(); start++ {
+			if _, seen := color[start]; seen || len(links[start]) == 0 {
+				continue
+			}
+
+			// Two-color the component containing start
+			var groups [2][]int
+			color[start] = 0
+			queue := []int{start}
+			for len(queue) > 0 {
+				pos := queue[0]
+				queue = queue[1:]
+				groups[color[pos]] = append(groups[color[pos]], pos)
+				for _, next := range links[pos] {
+					if _, seen := color[next]; !seen {
+						color[next] = 1 - color[pos]
+						queue = append(queue, next)
+					}
+				}
+			}
+			if len(groups[0])+len(groups[1]) < 3 {
+				continue
+			}
+
+			cells := append(slices.Clone(groups[0]), groups[1]...)
+			slices.Sort(cells)
+
+			// Color wrap
+			for _, group := range groups {
+				if !ls.seesAnother(group) {
+					continue
+				}
+				return &Step{
+					Technique:    SimpleColoring,
+					Cells:        cells,
+					Digits:       []int{val},
+					Eliminations: ls.eliminationsFrom(group, val),
+				}
+			}
+
+			// Color trap
+			var elims []Candidate
+			for pos := 0; pos < ls.Board.CellCount(); pos++ {
+				if ls.Candidates(pos)&digitMask(val) == 0 || slices.Contains(cells, pos) {
+					continue
+				}
+				if ls.seesAny(pos, groups[0]) && ls.seesAny(pos, groups[1]) {
+					elims = append(elims, Candidate{Pos: pos, Val: val})
+				}
+			}
+			if len(elims) > 0 {
+				return &Step{
+					Technique:    SimpleColoring,
+					Cells:        cells,
+					Digits:       []int{val},
+					Eliminations: elims,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// seesAnother reports whether any two of the cells see each other.
+func (ls *LogicalSolver) seesAnother(cells []int) bool {
+	for i, a := range cells {
+		for _, b := range cells[i+1:] {
+			if ls.geo().Sees(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// seesAny reports whether pos sees at least one of the cells.
+func (ls *LogicalSolver) seesAny(pos int, cells []int) bool {
+	for _, other := range cells {
+		if ls.geo().Sees(pos, other) {
+			return true
+		}
+	}
+	return false
+}
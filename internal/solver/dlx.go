@@ -0,0 +1,232 @@
+package solver
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// dancingLinks is a backend that treats Sudoku as an exact cover problem
+// and solves it with Knuth's Algorithm X on a toroidal doubly-linked matrix.
+type dancingLinks struct {
+	rng *rand.Rand
+}
+
+// search builds the cover matrix for the board's empty cells and enumerates its solutions.
+func (dl *dancingLinks) search(ctx context.Context, b *board.Board, visit func(*board.Board) bool) bool {
+	m := newCoverMatrix(b)
+	m.rng = dl.rng
+	return m.search(ctx, func(placed []Candidate) bool {
+		solution := b.Clone()
+		for _, c := range placed {
+			solution.SetForce(c.Pos, c.Val)
+		}
+		return visit(solution)
+	})
+}
+
+// coverMatrix is a sparse exact cover matrix stored as parallel node arrays.
+// Node 0 is the root and is followed by the column headers: one per cell,
+// then one per unit and digit, as every cell holds one digit and every unit holds each digit once.
+// Each candidate placement adds one row with a node per constraint it satisfies.
+type coverMatrix struct {
+	left, right, up, down []int
+	column                []int       // Column header of each node
+	row                   []int       // Index into rows of each node, -1 for headers
+	size                  []int       // Number of rows in each column, indexed by header node
+	rows                  []Candidate // Placement each matrix row stands for
+
+	headersOf func(c Candidate) []int // Header nodes of the constraints a placement satisfies
+	chosen    []int
+	rng       *rand.Rand
+}
+
+// newCoverMatrix builds a matrix with one row per candidate of every empty cell.
+func newCoverMatrix(b *board.Board) *coverMatrix {
+	g := b.Geometry()
+	var filled, open []Candidate
+	for pos := 0; pos < g.CellCount(); pos++ {
+		if val := b.Get(pos); val != board.EmptyCell {
+			filled = append(filled, Candidate{Pos: pos, Val: val})
+			continue
+		}
+		for _, val := range maskDigits(b.GetCandidatesMask(pos)) {
+			open = append(open, Candidate{Pos: pos, Val: val})
+		}
+	}
+
+	// A placement satisfies its cell, then its digit in each unit containing the cell
+	headersOf := func(c Candidate) []int {
+		units := g.UnitsOf(c.Pos)
+		headers := make([]int, 0, 1+len(units))
+		headers = append(headers, 1+c.Pos)
+		for _, u := range units {
+			headers = append(headers, 1+g.CellCount()+u*g.Size()+c.Val-1)
+		}
+		return headers
+	}
+
+	return buildCoverMatrix(1+g.CellCount()+len(g.Units())*g.Size(), headersOf, filled, open)
+}
+
+// buildCoverMatrix builds a matrix of the given number of header nodes, root included,
+// with one row per open placement. Constraints already satisfied by the filled placements
+// are left out of the header list.
+func buildCoverMatrix(headers int, headersOf func(c Candidate) []int, filled, open []Candidate) *coverMatrix {
+	m := &coverMatrix{
+		headersOf: headersOf,
+		size:      make([]int, headers),
+	}
+
+	satisfied := make([]bool, headers)
+	for _, c := range filled {
+		for _, h := range headersOf(c) {
+			satisfied[h] = true
+		}
+	}
+
+	for h := 0; h < headers; h++ {
+		node := m.addNode(h, -1)
+		m.left[node], m.right[node] = node, node
+	}
+
+	// A constraint left with no rows keeps an empty column, so the search fails at once
+	for h := 1; h < headers; h++ {
+		if !satisfied[h] {
+			m.left[h], m.right[h] = m.left[0], 0
+			m.right[m.left[0]] = h
+			m.left[0] = h
+		}
+	}
+
+	for _, c := range open {
+		m.addRow(c)
+	}
+
+	return m
+}
+
+// addNode appends a node to the bottom of a column and returns it.
+// Header nodes pass themselves as the column.
+func (m *coverMatrix) addNode(h, row int) int {
+	node := len(m.column)
+	m.column = append(m.column, h)
+	m.row = append(m.row, row)
+	m.left = append(m.left, node)
+	m.right = append(m.right, node)
+
+	if node == h {
+		m.up = append(m.up, node)
+		m.down = append(m.down, node)
+		return node
+	}
+	m.up = append(m.up, m.up[h])
+	m.down = append(m.down, h)
+	m.down[m.up[h]] = node
+	m.up[h] = node
+	m.size[h]++
+	return node
+}
+
+// addRow appends a matrix row for a placement.
+func (m *coverMatrix) addRow(c Candidate) {
+	row := len(m.rows)
+	m.rows = append(m.rows, c)
+
+	first := len(m.column)
+	headers := m.headersOf(c)
+	for i, h := range headers {
+		node := m.addNode(h, row)
+		m.left[node] = first + (i+len(headers)-1)%len(headers)
+		m.right[node] = first + (i+1)%len(headers)
+	}
+}
+
+// cover removes a column and every row intersecting it.
+func (m *coverMatrix) cover(h int) {
+	m.right[m.left[h]] = m.right[h]
+	m.left[m.right[h]] = m.left[h]
+	for i := m.down[h]; i != h; i = m.down[i] {
+		for j := m.right[i]; j != i; j = m.right[j] {
+			m.down[m.up[j]] = m.down[j]
+			m.up[m.down[j]] = m.up[j]
+			m.size[m.column[j]]--
+		}
+	}
+}
+
+// uncover restores a column removed by cover, in exactly reverse order.
+func (m *coverMatrix) uncover(h int) {
+	for i := m.up[h]; i != h; i = m.up[i] {
+		for j := m.left[i]; j != i; j = m.left[j] {
+			m.size[m.column[j]]++
+			m.down[m.up[j]] = j
+			m.up[m.down[j]] = j
+		}
+	}
+	m.right[m.left[h]] = h
+	m.left[m.right[h]] = h
+}
+
+// search runs Algorithm X, always branching on the column with the fewest rows,
+// and calls visit with the placements of each solution.
+// Returns false if the search was cut short by visit or ctx.
+func (m *coverMatrix) search(ctx context.Context, visit func(placed []Candidate) bool) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	default:
+	}
+
+	if m.right[0] == 0 {
+		placed := make([]Candidate, len(m.chosen))
+		for i, node := range m.chosen {
+			placed[i] = m.rows[m.row[node]]
+		}
+		return visit(placed)
+	}
+
+	best := m.right[0]
+	for h := m.right[best]; h != 0; h = m.right[h] {
+		if m.size[h] < m.size[best] {
+			best = h
+		}
+	}
+	if m.size[best] == 0 {
+		return true
+	}
+
+	var options []int
+	for r := m.down[best]; r != best; r = m.down[r] {
+		options = append(options, r)
+	}
+	if m.rng != nil {
+		m.rng.Shuffle(len(options), func(i, j int) {
+			options[i], options[j] = options[j], options[i]
+		})
+	}
+
+	m.cover(best)
+	defer m.uncover(best)
+
+	for _, r := range options {
+		m.chosen = append(m.chosen, r)
+		for j := m.right[r]; j != r; j = m.right[j] {
+			m.cover(m.column[j])
+		}
+
+		more := m.search(ctx, visit)
+
+		for j := m.left[r]; j != r; j = m.left[j] {
+			m.uncover(m.column[j])
+		}
+		m.chosen = m.chosen[:len(m.chosen)-1]
+
+		if !more {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,95 @@
+package solver
+
+import (
+	"math/bits"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// fishFinder returns a finder for a basic fish of size n (X-Wing, Swordfish, Jellyfish):
+// a digit confined to n columns within n rows, or vice versa,
+// which removes it from the rest of those columns (or rows).
+func fishFinder(technique Technique, n int) finder {
+	return func(ls *LogicalSolver) *Step {
+		for val := 1; val <= ls.Board.Size(); val++ {
+			if step := ls.findFish(technique, n, val, board.RowUnit); step != nil {
+				return step
+			}
+			if step := ls.findFish(technique, n, val, board.ColUnit); step != nil {
+				return step
+			}
+		}
+		return nil
+	}
+}
+
+// findFish searches for a fish on val whose base lines are of the given unit type.
+func (ls *LogicalSolver) findFish(technique Technique, n, val int, baseType board.UnitType) *Step {
+	g := ls.geo()
+	rows, cols := g.Units()[:g.Size()], g.Units()[g.Size():2*g.Size()]
+	baseUnits, coverUnits := rows, cols
+	coverOf := g.ColOf
+	if baseType == board.ColUnit {
+		baseUnits, coverUnits = cols, rows
+		coverOf = g.RowOf
+	}
+
+	// Collect base lines where val has between 2 and n places,
+	// along with the set of cover lines those places fall in
+	var lines []board.Unit
+	var lineCells [][]int
+	var lineCovers []uint
+	for _, u := range baseUnits {
+		cells := ls.cellsWith(u, val)
+		if len(cells) < 2 || len(cells) > n {
+			continue
+		}
+		var covers uint
+		for _, pos := range cells {
+			covers |= 1 << coverOf(pos)
+		}
+		lines = append(lines, u)
+		lineCells = append(lineCells, cells)
+		lineCovers = append(lineCovers, covers)
+	}
+
+	var step *Step
+	combinations(len(lines), n, func(idx []int) bool {
+		var covers uint
+		var base []int
+		for _, j := range idx {
+			covers |= lineCovers[j]
+			base = append(base, lineCells[j]...)
+		}
+		if bits.OnesCount(covers) != n {
+			return true
+		}
+
+		var elims []Candidate
+		var units []board.Unit
+		for _, j := range idx {
+			units = append(units, lines[j])
+		}
+		for c := range coverUnits {
+			if covers&(1<<c) == 0 {
+				continue
+			}
+			units = append(units, coverUnits[c])
+			elims = append(elims, ls.eliminationsFrom(coverUnits[c].Cells, val, base...)...)
+		}
+		if len(elims) == 0 {
+			return true
+		}
+
+		step = &Step{
+			Technique:    technique,
+			Units:        units,
+			Cells:        base,
+			Digits:       []int{val},
+			Eliminations: elims,
+		}
+		return false
+	})
+
+	return step
+}
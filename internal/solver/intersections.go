@@ -0,0 +1,81 @@
+package solver
+
+import (
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// findPointing finds a digit confined to one row or column within a box,
+// which removes it from the rest of that row or column.
+func findPointing(ls *LogicalSolver) *Step {
+	g := ls.geo()
+	for _, box := range g.Units()[2*g.Size() : 3*g.Size()] {
+		for val := 1; val <= g.Size(); val++ {
+			cells := ls.cellsWith(box, val)
+			if len(cells) < 2 {
+				continue
+			}
+
+			var line board.Unit
+			switch {
+			case sameLine(cells, g.RowOf):
+				line = g.RowUnitOf(cells[0])
+			case sameLine(cells, g.ColOf):
+				line = g.ColUnitOf(cells[0])
+			default:
+				continue
+			}
+
+			elims := ls.eliminationsFrom(line.Cells, val, box.Cells...)
+			if len(elims) == 0 {
+				continue
+			}
+			return &Step{
+				Technique:    PointingCandidates,
+				Units:        []board.Unit{box, line},
+				Cells:        cells,
+				Digits:       []int{val},
+				Eliminations: elims,
+			}
+		}
+	}
+	return nil
+}
+
+// findClaiming finds a digit confined to one box within a row or column,
+// which removes it from the rest of that box.
+func findClaiming(ls *LogicalSolver) *Step {
+	g := ls.geo()
+	for _, line := range g.Units()[:2*g.Size()] {
+		for val := 1; val <= g.Size(); val++ {
+			cells := ls.cellsWith(line, val)
+			if len(cells) < 2 || !sameLine(cells, g.BoxOf) {
+				continue
+			}
+
+			box := g.BoxUnitOf(cells[0])
+			elims := ls.eliminationsFrom(box.Cells, val, line.Cells...)
+			if len(elims) == 0 {
+				continue
+			}
+			return &Step{
+				Technique:    ClaimingCandidates,
+				Units:        []board.Unit{line, box},
+				Cells:        cells,
+				Digits:       []int{val},
+				Eliminations: elims,
+			}
+		}
+	}
+	return nil
+}
+
+// sameLine reports whether all cells have the same index under indexOf,
+// such as g.RowOf to test for a single row.
+func sameLine(cells []int, indexOf func(int) int) bool {
+	for _, pos := range cells[1:] {
+		if indexOf(pos) != indexOf(cells[0]) {
+			return false
+		}
+	}
+	return true
+}
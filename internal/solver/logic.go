@@ -0,0 +1,263 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"slices"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+var (
+	ErrStuck            = errors.New("no logical technique applies")
+	ErrUnknownTechnique = errors.New("unknown solving technique")
+)
+
+// finder searches for one application of a technique without modifying the grid.
+// Returns nil if the technique does not apply.
+type finder func(*LogicalSolver) *Step
+
+// finders holds the search function of each technique, indexed by Technique.
+var finders = [techniqueCount]finder{
+	HiddenSingle:       findHiddenSingle,
+	NakedSingle:        findNakedSingle,
+	PointingCandidates: findPointing,
+	ClaimingCandidates: findClaiming,
+	NakedPair:          nakedSubsetFinder(NakedPair, 2),
+	HiddenPair:         hiddenSubsetFinder(HiddenPair, 2),
+	NakedTriple:        nakedSubsetFinder(NakedTriple, 3),
+	HiddenTriple:       hiddenSubsetFinder(HiddenTriple, 3),
+	NakedQuad:          nakedSubsetFinder(NakedQuad, 4),
+	HiddenQuad:         hiddenSubsetFinder(HiddenQuad, 4),
+	XWing:              fishFinder(XWing, 2),
+	Swordfish:          fishFinder(Swordfish, 3),
+	Jellyfish:          fishFinder(Jellyfish, 4),
+	XYWing:             findXYWing,
+	XYZWing:            findXYZWing,
+	WWing:              findWWing,
+	SimpleColoring:     findSimpleColoring,
+	UniqueRectangle:    findUniqueRectangle,
+}
+
+// LogicalSolver solves puzzles the way a person would, applying named techniques
+// in order of increasing difficulty and recording every step taken.
+// Eliminations are recorded in the board's pencil marks, so a board that already
+// carries eliminations continues from them.
+type LogicalSolver struct {
+	Board *board.Board
+	steps []Step
+}
+
+// NewLogical creates a logical solver for the given board.
+func NewLogical(b *board.Board) *LogicalSolver {
+	return &LogicalSolver{
+		Board: b.Clone(),
+	}
+}
+
+// Solve applies techniques until the puzzle is solved or no technique applies.
+// Returns the steps taken, along with ErrStuck if logic alone cannot finish the puzzle.
+func (ls *LogicalSolver) Solve() ([]Step, error) {
+	return ls.SolveContext(context.Background())
+}
+
+// SolveContext is like Solve but stops between steps once ctx is done,
+// returning the steps so far along with ErrTimeout or ctx.Err().
+func (ls *LogicalSolver) SolveContext(ctx context.Context) ([]Step, error) {
+	if !ls.Board.IsValid() {
+		return nil, ErrInvalidPuzzle
+	}
+
+	for ls.Board.EmptyCount() > 0 {
+		if ctx.Err() != nil {
+			return ls.steps, contextError(ctx)
+		}
+		step, err := ls.Next()
+		if err != nil {
+			return ls.steps, err
+		}
+		if step == nil {
+			return ls.steps, ErrStuck
+		}
+	}
+
+	return ls.steps, nil
+}
+
+// Next finds the easiest applicable step, applies it and returns it.
+// Returns nil without error once the board is solved or no technique applies.
+func (ls *LogicalSolver) Next() (*Step, error) {
+	step, err := ls.Find()
+	if step == nil || err != nil {
+		return nil, err
+	}
+	ls.Apply(step)
+	return step, nil
+}
+
+// Find returns the easiest applicable step without applying it.
+// Returns ErrNoSolution if the grid has reached a contradiction.
+func (ls *LogicalSolver) Find() (*Step, error) {
+	if ls.hasContradiction() {
+		return nil, ErrNoSolution
+	}
+	for _, find := range finders {
+		if step := find(ls); step != nil {
+			step.Geometry = ls.geo()
+			return step, nil
+		}
+	}
+	return nil, nil
+}
+
+// Apply performs the placements and eliminations of a step and records it.
+func (ls *LogicalSolver) Apply(step *Step) {
+	for _, c := range step.Placements {
+		ls.place(c.Pos, c.Val)
+	}
+	for _, c := range step.Eliminations {
+		ls.eliminate(c.Pos, c.Val)
+	}
+	ls.steps = append(ls.steps, *step)
+}
+
+// Steps returns the steps applied so far.
+func (ls *LogicalSolver) Steps() []Step {
+	return ls.steps
+}
+
+// geo returns the geometry of the board being solved.
+func (ls *LogicalSolver) geo() *board.Geometry {
+	return ls.Board.Geometry()
+}
+
+// Candidates returns the remaining candidate mask of a cell, or 0 if the cell is filled.
+func (ls *LogicalSolver) Candidates(pos int) uint {
+	if ls.Board.Get(pos) != board.EmptyCell {
+		return 0
+	}
+	return ls.Board.GetCandidatesMask(pos)
+}
+
+// place sets a value, which implicitly removes it from the candidates of its peers.
+func (ls *LogicalSolver) place(pos, val int) {
+	if ls.Board.Get(pos) != board.EmptyCell {
+		return
+	}
+	ls.Board.SetForce(pos, val)
+}
+
+// eliminate removes a candidate from a cell's pencil marks.
+func (ls *LogicalSolver) eliminate(pos, val int) {
+	ls.Board.EliminateCandidate(pos, val)
+}
+
+// hasContradiction reports whether an empty cell has no candidates,
+// a unit has no place left for a missing digit, or a constraint is broken.
+func (ls *LogicalSolver) hasContradiction() bool {
+	for _, c := range ls.Board.Constraints() {
+		if !c.Check(ls.Board) {
+			return true
+		}
+	}
+	for _, u := range ls.geo().Units() {
+		var placed, possible uint
+		for _, pos := range u.Cells {
+			if val := ls.Board.Get(pos); val != board.EmptyCell {
+				placed |= digitMask(val)
+			} else if ls.Candidates(pos) == 0 {
+				return true
+			} else {
+				possible |= ls.Candidates(pos)
+			}
+		}
+		if placed|possible != ls.geo().AllDigits() {
+			return true
+		}
+	}
+	return false
+}
+
+// cellsWith returns the empty cells of a unit that still hold a candidate.
+func (ls *LogicalSolver) cellsWith(u board.Unit, val int) []int {
+	var cells []int
+	for _, pos := range u.Cells {
+		if ls.Candidates(pos)&digitMask(val) != 0 {
+			cells = append(cells, pos)
+		}
+	}
+	return cells
+}
+
+// eliminationsFrom lists the candidates of val present in cells, skipping excluded cells.
+func (ls *LogicalSolver) eliminationsFrom(cells []int, val int, exclude ...int) []Candidate {
+	var elims []Candidate
+	for _, pos := range cells {
+		if ls.Candidates(pos)&digitMask(val) != 0 && !slices.Contains(exclude, pos) {
+			elims = append(elims, Candidate{Pos: pos, Val: val})
+		}
+	}
+	return elims
+}
+
+// commonPeers returns the cells that see every one of the given cells.
+func (ls *LogicalSolver) commonPeers(cells ...int) []int {
+	var common []int
+	for _, pos := range ls.geo().Peers(cells[0]) {
+		seesAll := true
+		for _, other := range cells[1:] {
+			if !ls.geo().Sees(pos, other) {
+				seesAll = false
+				break
+			}
+		}
+		if seesAll {
+			common = append(common, pos)
+		}
+	}
+	return common
+}
+
+// digitMask returns the candidate bit of a digit.
+func digitMask(val int) uint {
+	return uint(1) << (val - 1)
+}
+
+// maskDigits returns the digits set in a candidate mask in ascending order.
+func maskDigits(mask uint) []int {
+	digits := make([]int, 0, bits.OnesCount(mask))
+	for mask != 0 {
+		digits = append(digits, bits.TrailingZeros(mask)+1)
+		mask &= mask - 1
+	}
+	return digits
+}
+
+// combinations calls fn with every k-element subset of the indices 0..n-1 in lexicographic order.
+// Iteration stops early when fn returns false.
+func combinations(n, k int, fn func([]int) bool) {
+	if k > n {
+		return
+	}
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+	for {
+		if !fn(idx) {
+			return
+		}
+		i := k - 1
+		for i >= 0 && idx[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		idx[i]++
+		for j := i + 1; j < k; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package solver
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// MultiSolver solves multi-grid puzzles such as Samurai.
+// A shared cell is a single cell of the search that satisfies the units of every grid covering it,
+// so the grids always agree. The search is always an exact cover search, whatever the Algorithm option.
+type MultiSolver struct {
+	Multi   *board.Multi
+	options *Options
+	rng     *rand.Rand
+}
+
+// NewMulti creates a solver for the given multi-grid puzzle.
+func NewMulti(m *board.Multi, options *Options) *MultiSolver {
+	if options == nil {
+		options = DefaultOptions()
+	}
+
+	s := &MultiSolver{
+		Multi:   m.Clone(),
+		options: options,
+	}
+
+	if options.Randomize {
+		s.rng = options.makeRand()
+	}
+
+	return s
+}
+
+// Solve attempts to solve the puzzle.
+// Returns the solved puzzle or an error if unsolvable.
+func (s *MultiSolver) Solve() (*board.Multi, error) {
+	return s.SolveContext(s.options.baseContext())
+}
+
+// SolveContext is like Solve but stops early once ctx is done,
+// returning ErrTimeout if its deadline passed or ctx.Err() if it was cancelled.
+func (s *MultiSolver) SolveContext(ctx context.Context) (*board.Multi, error) {
+	var solution *board.Multi
+	if _, err := s.SolveAllContext(ctx, func(m *board.Multi) bool {
+		solution = m
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	if solution == nil {
+		return nil, ErrNoSolution
+	}
+	s.Multi = solution
+	return s.Multi, nil
+}
+
+// SolveAll calls visit with each solution in turn, like Solver.SolveAll.
+func (s *MultiSolver) SolveAll(visit func(*board.Multi) bool) (Result, error) {
+	return s.SolveAllContext(s.options.baseContext(), visit)
+}
+
+// SolveAllContext is like SolveAll but stops early once ctx is done,
+// returning the partial result along with ErrTimeout or ctx.Err().
+func (s *MultiSolver) SolveAllContext(ctx context.Context, visit func(*board.Multi) bool) (Result, error) {
+	var result Result
+	if !s.Multi.IsValid() {
+		return result, ErrInvalidPuzzle
+	}
+
+	ctx, cancel := s.options.makeContext(ctx)
+	defer cancel()
+
+	m := newMultiCoverMatrix(s.Multi)
+	m.rng = s.rng
+	limit := s.options.MaxSolutions
+	result.Complete = m.search(ctx, func(placed []Candidate) bool {
+		result.Count++
+		if visit != nil {
+			solution := s.Multi.Clone()
+			for _, c := range placed {
+				solution.SetForce(c.Pos, c.Val)
+			}
+			if !visit(solution) {
+				return false
+			}
+		}
+		return limit <= 0 || result.Count < limit
+	})
+
+	if !result.Complete && ctx.Err() != nil {
+		return result, contextError(ctx)
+	}
+	return result, nil
+}
+
+// Count counts the puzzle's solutions, up to MaxSolutions.
+func (s *MultiSolver) Count() (Result, error) {
+	return s.SolveAllContext(s.options.baseContext(), nil)
+}
+
+// CountContext is like Count but stops early once ctx is done.
+func (s *MultiSolver) CountContext(ctx context.Context) (Result, error) {
+	return s.SolveAllContext(ctx, nil)
+}
+
+// newMultiCoverMatrix builds a matrix with one row per candidate of every empty canvas cell.
+// Its headers are one per covered canvas cell, then one per unit and digit of every grid,
+// so a shared box has a header per grid which its cells always cover together.
+func newMultiCoverMatrix(m *board.Multi) *coverMatrix {
+	l := m.Layout()
+	g := l.Geometry()
+
+	// Number the covered canvas cells, so that the gaps between grids get no header
+	index := make(map[int]int, l.CellCount())
+	for i, cell := range l.Cells() {
+		index[cell] = i
+	}
+	gridHeaders := len(g.Units()) * g.Size()
+
+	headersOf := func(c Candidate) []int {
+		headers := []int{1 + index[c.Pos]}
+		for _, gc := range l.GridCells(c.Pos) {
+			for _, u := range g.UnitsOf(gc.Pos) {
+				headers = append(headers, 1+l.CellCount()+gc.Grid*gridHeaders+u*g.Size()+c.Val-1)
+			}
+		}
+		return headers
+	}
+
+	var filled, open []Candidate
+	for _, cell := range l.Cells() {
+		if val := m.Get(cell); val != board.EmptyCell {
+			filled = append(filled, Candidate{Pos: cell, Val: val})
+			continue
+		}
+		for _, val := range maskDigits(m.GetCandidatesMask(cell)) {
+			open = append(open, Candidate{Pos: cell, Val: val})
+		}
+	}
+
+	return buildCoverMatrix(1+l.CellCount()+l.GridCount()*gridHeaders, headersOf, filled, open)
+}
@@ -2,14 +2,51 @@ package solver
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 )
 
+// Algorithm selects the search backend a solver uses once propagation stalls.
+type Algorithm int
+
+const (
+	Backtracking Algorithm = iota // MRV backtracking with constraint propagation
+	DancingLinks                  // Knuth's Algorithm X on an exact cover matrix
+)
+
+// String returns the name of the algorithm.
+func (a Algorithm) String() string {
+	switch a {
+	case Backtracking:
+		return "backtracking"
+	case DancingLinks:
+		return "dlx"
+	default:
+		return fmt.Sprintf("Algorithm(%d)", int(a))
+	}
+}
+
+// ParseAlgorithm converts an algorithm name into an Algorithm.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	for _, a := range []Algorithm{Backtracking, DancingLinks} {
+		if strings.EqualFold(s, a.String()) {
+			return a, nil
+		}
+	}
+	return Backtracking, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, s)
+}
+
 // Options configures the solver behavior.
 type Options struct {
+	Algorithm    Algorithm       // Algorithm selects the search backend
 	MaxSolutions int             // MaxSolutions limits solution search (0 = unlimited)
 	Timeout      time.Duration   // Timeout limits solving time
 	Randomize    bool            // Randomize solution selection for puzzle generation
+	Seed         int64           // Seed for reproducible randomization (0 = random)
+	Source       rand.Source     // Source of randomness, overrides Seed when set
 	Context      context.Context // Context for cancellation
 }
 
@@ -31,16 +68,40 @@ func GenerateOptions() *Options {
 	}
 }
 
-// makeContext creates a context with timeout if specified.
-func (s *Solver) makeContext() (context.Context, context.CancelFunc) {
-	ctx := s.options.Context
-	if ctx == nil {
-		ctx = context.Background()
+// makeRand creates the random number generator used when Randomize is set.
+func (o *Options) makeRand() *rand.Rand {
+	switch {
+	case o.Source != nil:
+		return rand.New(o.Source)
+	case o.Seed != 0:
+		return rand.New(rand.NewSource(o.Seed))
+	default:
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+}
+
+// baseContext returns the Context option, or the background context if unset.
+func (o *Options) baseContext() context.Context {
+	if o.Context == nil {
+		return context.Background()
 	}
+	return o.Context
+}
 
-	if s.options.Timeout > 0 {
-		return context.WithTimeout(ctx, s.options.Timeout)
+// makeContext derives the search context from ctx, adding the timeout if specified.
+func (o *Options) makeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout > 0 {
+		return context.WithTimeout(ctx, o.Timeout)
 	}
 
 	return context.WithCancel(ctx)
 }
+
+// contextError converts a finished context into the error reported to callers:
+// ErrTimeout once a deadline passes, or the context's own error on cancellation.
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ctx.Err()
+}
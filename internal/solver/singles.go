@@ -0,0 +1,51 @@
+package solver
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// findHiddenSingle finds a digit with only one possible cell in a unit.
+// Boxes are searched before rows and columns, since they are easiest to spot.
+func findHiddenSingle(ls *LogicalSolver) *Step {
+	g := ls.geo()
+	all := g.Units()
+	ordered := append(slices.Clone(all[2*g.Size():]), all[:2*g.Size()]...)
+
+	for _, u := range ordered {
+		for val := 1; val <= g.Size(); val++ {
+			cells := ls.cellsWith(u, val)
+			if len(cells) != 1 {
+				continue
+			}
+			return &Step{
+				Technique:  HiddenSingle,
+				Units:      []board.Unit{u},
+				Cells:      cells,
+				Digits:     []int{val},
+				Placements: []Candidate{{Pos: cells[0], Val: val}},
+			}
+		}
+	}
+	return nil
+}
+
+// findNakedSingle finds a cell with only one remaining candidate.
+func findNakedSingle(ls *LogicalSolver) *Step {
+	for pos := 0; pos < ls.Board.CellCount(); pos++ {
+		mask := ls.Candidates(pos)
+		if bits.OnesCount(mask) != 1 {
+			continue
+		}
+		val := bits.TrailingZeros(mask) + 1
+		return &Step{
+			Technique:  NakedSingle,
+			Cells:      []int{pos},
+			Digits:     []int{val},
+			Placements: []Candidate{{Pos: pos, Val: val}},
+		}
+	}
+	return nil
+}
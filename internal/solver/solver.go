@@ -3,9 +3,9 @@ package solver
 import (
 	"context"
 	"errors"
+	"iter"
 	"math/bits"
 	"math/rand"
-	"time"
 
 	"github.com/rybkr/sudoku/internal/board"
 )
@@ -15,6 +15,7 @@ var (
 	ErrMultipleSolutions = errors.New("puzzle has multiple solutions")
 	ErrInvalidPuzzle     = errors.New("puzzle violates Sudoku constraints")
 	ErrTimeout           = errors.New("solver timeout exceeded")
+	ErrUnknownAlgorithm  = errors.New("unknown solver algorithm")
 )
 
 // Solver implements algorithms for solving Sudoku puzzles.
@@ -36,7 +37,7 @@ func New(b *board.Board, options *Options) *Solver {
 	}
 
 	if options.Randomize {
-		s.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+		s.rng = options.makeRand()
 	}
 
 	return s
@@ -45,43 +46,161 @@ func New(b *board.Board, options *Options) *Solver {
 // Solve attempts to solve the puzzle.
 // Returns the solved board or an error if unsolvable.
 func (s *Solver) Solve() (*board.Board, error) {
+	return s.SolveContext(s.options.baseContext())
+}
+
+// SolveContext is like Solve but stops early once ctx is done,
+// returning ErrTimeout if its deadline passed or ctx.Err() if it was cancelled.
+// The Timeout option still applies on top of ctx.
+func (s *Solver) SolveContext(ctx context.Context) (*board.Board, error) {
 	if !s.Board.IsValid() {
 		return nil, ErrInvalidPuzzle
 	}
 
-	// If the board is empty, fill 27 independent cells for efficiency
-	if s.Board.EmptyCount() == board.CellCount {
-		s.fillThreeBoxes()
+	ctx, cancel := s.options.makeContext(ctx)
+	defer cancel()
+
+	// If the board is empty, fill independent boxes for efficiency,
+	// unless they are jigsaw regions or extra units, rules or constraints tie them together
+	g := s.Board.Geometry()
+	if s.Board.EmptyCount() == s.Board.CellCount() && !g.Jigsaw() && g.ExtraUnits() == 0 && g.Rules() == 0 && len(s.Board.Constraints()) == 0 {
+		s.fillIndependentBoxes()
 	}
 
 	// Constraint propagation is faster, try this first
-	if err := s.PropagateConstraints(); err != nil {
+	if err := s.propagate(ctx); err != nil {
 		return nil, err
 	}
 	if s.Board.EmptyCount() == 0 {
 		return s.Board, nil
 	}
 
-	// Start backtracking with MRV heuristic
-	// MRV = Minimum Remaining Values, guess on the most constrained cells first
-	// to reduce total search space
-	ctx, cancel := s.makeContext()
-	defer cancel()
+	var solution *board.Board
+	s.backend().search(ctx, s.Board, func(b *board.Board) bool {
+		solution = b
+		return false
+	})
 
-	if !s.backtrack(ctx) {
+	if solution == nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 		return nil, ErrNoSolution
-	} else {
-		return s.Board, nil
+	}
+	s.Board = solution
+	return s.Board, nil
+}
+
+// Result reports the outcome of a search for multiple solutions.
+type Result struct {
+	Count    int  // Count is the number of solutions found
+	Complete bool // Complete reports whether the search space was exhausted rather than cut short
+}
+
+// SolveAll calls visit with each solution in turn until MaxSolutions have been found,
+// the search space is exhausted, or visit returns false. A nil visit just counts.
+// Reaching MaxSolutions leaves the result incomplete, even if no further solutions exist.
+// Returns the partial result along with ErrTimeout if the search timed out.
+func (s *Solver) SolveAll(visit func(*board.Board) bool) (Result, error) {
+	return s.SolveAllContext(s.options.baseContext(), visit)
+}
+
+// SolveAllContext is like SolveAll but stops early once ctx is done,
+// returning the partial result along with ErrTimeout or ctx.Err().
+func (s *Solver) SolveAllContext(ctx context.Context, visit func(*board.Board) bool) (Result, error) {
+	var result Result
+	if !s.Board.IsValid() {
+		return result, ErrInvalidPuzzle
+	}
+
+	ctx, cancel := s.options.makeContext(ctx)
+	defer cancel()
+
+	limit := s.options.MaxSolutions
+	result.Complete = s.backend().search(ctx, s.Board, func(b *board.Board) bool {
+		result.Count++
+		if visit != nil && !visit(b) {
+			return false
+		}
+		return limit <= 0 || result.Count < limit
+	})
+
+	if !result.Complete && ctx.Err() != nil {
+		return result, contextError(ctx)
+	}
+	return result, nil
+}
+
+// Solutions returns an iterator over the puzzle's solutions, up to MaxSolutions.
+// Use SolveAll instead to learn whether the search was complete or failed.
+func (s *Solver) Solutions() iter.Seq[*board.Board] {
+	return func(yield func(*board.Board) bool) {
+		s.SolveAll(yield)
+	}
+}
+
+// Count counts the puzzle's solutions, up to MaxSolutions.
+func (s *Solver) Count() (Result, error) {
+	return s.SolveAllContext(s.options.baseContext(), nil)
+}
+
+// CountContext is like Count but stops early once ctx is done.
+func (s *Solver) CountContext(ctx context.Context) (Result, error) {
+	return s.SolveAllContext(ctx, nil)
+}
+
+// backend is a search algorithm that enumerates the solutions of a board.
+type backend interface {
+	// search calls visit with each solution of b until visit returns false or ctx is done.
+	// The board passed to visit belongs to the callee and may be kept.
+	// Returns false if the search was cut short.
+	search(ctx context.Context, b *board.Board, visit func(*board.Board) bool) bool
+}
+
+// backend returns the search algorithm selected in the options.
+// The exact cover matrix only encodes the units, so boards with rules or constraints are always backtracked.
+func (s *Solver) backend() backend {
+	switch {
+	case s.options.Algorithm == DancingLinks && s.Board.Geometry().Rules() == 0 && len(s.Board.Constraints()) == 0:
+		return &dancingLinks{rng: s.rng}
+	default:
+		return &backtracker{rng: s.rng}
 	}
 }
 
+// backtracker is a backend that guesses with the MRV heuristic,
+// propagating constraints after every guess.
+// MRV = Minimum Remaining Values, guess on the most constrained cells first
+// to reduce total search space
+type backtracker struct {
+	rng *rand.Rand
+}
+
+// search runs the backtracking search on a private copy of the board.
+func (bt *backtracker) search(ctx context.Context, b *board.Board, visit func(*board.Board) bool) bool {
+	s := &Solver{
+		Board:   b.Clone(),
+		options: &Options{Randomize: bt.rng != nil},
+		rng:     bt.rng,
+	}
+	return s.backtrack(ctx, visit)
+}
+
 // PropagateConstraints applies constraint propagation techniques.
 func (s *Solver) PropagateConstraints() error {
+	return s.propagate(context.Background())
+}
+
+// propagate applies constraint propagation until nothing changes or ctx is done.
+func (s *Solver) propagate(ctx context.Context) error {
 	changed := true
 	iterations := 0
-	maxIterations := board.CellCount * board.CellCount
+	maxIterations := s.Board.CellCount() * s.Board.CellCount()
 
 	for changed && iterations < maxIterations {
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
 		changed = false
 		iterations++
 
@@ -104,7 +223,7 @@ func (s *Solver) PropagateConstraints() error {
 func (s *Solver) applyNakedSingles() bool {
 	changed := false
 
-	for pos := 0; pos < board.CellCount; pos++ {
+	for pos := 0; pos < s.Board.CellCount(); pos++ {
 		if s.Board.Get(pos) == board.EmptyCell {
 			mask := s.Board.GetCandidatesMask(pos)
 
@@ -128,138 +247,86 @@ func (s *Solver) applyNakedSingles() bool {
 func (s *Solver) applyHiddenSingles() bool {
 	changed := false
 
-	for row := 0; row < 9; row++ {
-		changed = s.findHiddenSinglesInRow(row) || changed
-	}
-	for col := 0; col < 9; col++ {
-		changed = s.findHiddenSinglesInCol(col) || changed
-	}
-	for box := 0; box < 9; box++ {
-		changed = s.findHiddenSinglesInBox(box) || changed
+	for _, u := range s.Board.Geometry().Units() {
+		changed = s.findHiddenSinglesInUnit(u) || changed
 	}
 
 	return changed
 }
 
-// findHiddenSinglesInRow checks for hidden singles in the provided row.
-func (s *Solver) findHiddenSinglesInRow(row int) bool {
+// findHiddenSinglesInUnit checks for hidden singles in the provided row, column or box.
+func (s *Solver) findHiddenSinglesInUnit(u board.Unit) bool {
 	changed := false
 
-	// Track where each value can go
-	valuePossibilities := make([][]int, 10)
-
-	for col := 0; col < 9; col++ {
-		if s.Board.Get(board.MakePos(row, col)) == board.EmptyCell {
-			candidates := s.Board.GetCandidates(board.MakePos(row, col))
-			for _, val := range candidates {
-				valuePossibilities[val] = append(valuePossibilities[val], row*9+col)
-			}
-		}
-	}
-
-	// Find values with only one possible position
-	for val := 1; val <= 9; val++ {
-		if len(valuePossibilities[val]) == 1 {
-			pos := valuePossibilities[val][0]
-			s.Board.SetForce(pos, val)
-			changed = true
-		}
-	}
+	// Track which values can go in one place or more, and where each was first seen.
+	// Masks rather than lists keep this cheap, since constraints make every candidate lookup dearer.
+	var once, twice uint
+	var first [board.MaxSize + 1]int
 
-	return changed
-}
-
-// findHiddenSinglesInCol checks for hidden singles in the provided col.
-func (s *Solver) findHiddenSinglesInCol(col int) bool {
-	changed := false
-
-	// Track where each value can go
-	valuePossibilities := make([][]int, 10)
-
-	for row := 0; row < 9; row++ {
-		if s.Board.Get(board.MakePos(row, col)) == board.EmptyCell {
-			candidates := s.Board.GetCandidates(board.MakePos(row, col))
-			for _, val := range candidates {
-				valuePossibilities[val] = append(valuePossibilities[val], row*9+col)
+	for _, pos := range u.Cells {
+		if s.Board.Get(pos) == board.EmptyCell {
+			mask := s.Board.GetCandidatesMask(pos)
+			for m := mask &^ once; m != 0; m &= m - 1 {
+				first[bits.TrailingZeros(m)+1] = pos
 			}
+			twice |= once & mask
+			once |= mask
 		}
 	}
 
 	// Find values with only one possible position
-	for val := 1; val <= 9; val++ {
-		if len(valuePossibilities[val]) == 1 {
-			pos := valuePossibilities[val][0]
-			s.Board.SetForce(pos, val)
-			changed = true
-		}
-	}
-
-	return changed
-}
-
-// findHiddenSinglesInBox checks for hidden singles in the provided 3x3 box.
-func (s *Solver) findHiddenSinglesInBox(box int) bool {
-	changed := false
-	valuePossibilities := make([][]int, 10)
-
-	startPos := 3*(box%3) + 27*int(box/3)
-	startRow := int(startPos / 9)
-	startCol := startPos % 9
-
-	for dr := 0; dr < 3; dr++ {
-		for dc := 0; dc < 3; dc++ {
-			if s.Board.Get(board.MakePos(startRow+dr, startCol+dc)) == board.EmptyCell {
-				candidates := s.Board.GetCandidates(board.MakePos(startRow+dr, startCol+dc))
-				for _, val := range candidates {
-					valuePossibilities[val] = append(valuePossibilities[val], (startRow+dr)*9+startCol+dc)
-				}
-			}
-		}
-	}
-
-	for val := 1; val <= 9; val++ {
-		if len(valuePossibilities[val]) == 1 {
-			pos := valuePossibilities[val][0]
-			s.Board.SetForce(pos, val)
-			changed = true
+	for m := once &^ twice; m != 0; m &= m - 1 {
+		val := bits.TrailingZeros(m) + 1
+		pos := first[val]
+		if s.Board.Get(pos) != board.EmptyCell {
+			continue // Two values share one cell, a contradiction surfaces later
 		}
+		s.Board.SetForce(pos, val)
+		changed = true
 	}
 
 	return changed
 }
 
 // hasContradiction checks if the board has reached an invalid state.
+// Singles are placed without checking constraints, so those are checked here.
 func (s *Solver) hasContradiction() bool {
-	for pos := 0; pos < board.CellCount; pos++ {
+	for pos := 0; pos < s.Board.CellCount(); pos++ {
 		if s.Board.Get(pos) == board.EmptyCell && s.Board.GetCandidatesMask(pos) == 0 {
 			return true
 		}
 	}
+	for _, c := range s.Board.Constraints() {
+		if !c.Check(s.Board) {
+			return true
+		}
+	}
 	return false
 }
 
 // backtrack implements recursive backtracking with MRV heuristic.
-func (s *Solver) backtrack(ctx context.Context) bool {
+// Returns false if the search was cut short by visit or ctx.
+func (s *Solver) backtrack(ctx context.Context, visit func(*board.Board) bool) bool {
 	select {
 	case <-ctx.Done():
 		return false
 	default:
 	}
 
-	// Apply constraint propagation at each level
-	if err := s.PropagateConstraints(); err != nil {
-		return false
+	// Apply constraint propagation at each level, a contradiction is just a dead end
+	if err := s.propagate(ctx); err != nil {
+		return ctx.Err() == nil
 	}
 
 	// Check if we've already solved it
 	if s.Board.EmptyCount() == 0 {
-		return true
+		return visit(s.Board.Clone())
 	}
 
 	// Find the cell with the minimum remaining values
 	pos, candidates := s.FindMRVCell()
 	if len(candidates) == 0 {
-		return false
+		return true
 	}
 
 	// Randomize candidates if needed
@@ -269,24 +336,27 @@ func (s *Solver) backtrack(ctx context.Context) bool {
 		})
 	}
 
+	// Propagation in deeper levels fills more than just pos,
+	// so restore the whole board after each guess
+	snapshot := s.Board.Clone()
 	for _, val := range candidates {
 		s.Board.SetForce(pos, val)
-		if s.backtrack(ctx) {
-			return true
+		if !s.backtrack(ctx, visit) {
+			return false
 		}
-		s.Board.Clear(pos)
+		s.Board.CopyFrom(snapshot)
 	}
 
-	return false
+	return true
 }
 
 // FindMRVCell finds the empty cell with fewest candidates.
 func (s *Solver) FindMRVCell() (int, []int) {
 	mrvPos := -1
-	mrvCount := 10
+	mrvCount := s.Board.Size() + 1
 	var mrvCandidates []int
 
-	for pos := 0; pos < board.CellCount; pos++ {
+	for pos := 0; pos < s.Board.CellCount(); pos++ {
 		if s.Board.Get(pos) == board.EmptyCell {
 			candidates := s.Board.GetCandidates(pos)
 			count := len(candidates)
@@ -306,27 +376,37 @@ func (s *Solver) FindMRVCell() (int, []int) {
 	return mrvPos, mrvCandidates
 }
 
-// fillThreeBoxes fills three 3x3 boxes (27 cells total) that are all independent.
-func (s *Solver) fillThreeBoxes() {
-	boxColumns := []int{0, 3, 6}
+// fillIndependentBoxes fills boxes along the diagonal of the box grid,
+// which share no row or column and so can take any arrangement of digits.
+// On a 9x9 board that is three boxes, 27 cells in total.
+func (s *Solver) fillIndependentBoxes() {
+	g := s.Board.Geometry()
+	bands, stacks := g.Size()/g.BoxRows(), g.Size()/g.BoxCols()
+
+	boxColumns := make([]int, stacks)
+	for i := range boxColumns {
+		boxColumns[i] = i * g.BoxCols()
+	}
 	if s.options.Randomize && s.rng != nil {
 		s.rng.Shuffle(len(boxColumns), func(i, j int) {
 			boxColumns[i], boxColumns[j] = boxColumns[j], boxColumns[i]
 		})
 	}
-	nums := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	nums := make([]int, g.Size())
+	for i := range nums {
+		nums[i] = i + 1
+	}
 
-	for i, boxRow := range []int{0, 3, 6} {
-		boxCol := boxColumns[i]
+	for i := 0; i < min(bands, stacks); i++ {
+		boxRow, boxCol := i*g.BoxRows(), boxColumns[i]
 		if s.options.Randomize && s.rng != nil {
 			s.rng.Shuffle(len(nums), func(i, j int) {
 				nums[i], nums[j] = nums[j], nums[i]
 			})
 		}
 		for j, val := range nums {
-			dr, dc := int(j/3), j%3
-			pos := (boxRow+dr)*9 + boxCol + dc
-			s.Board.SetForce(pos, val)
+			dr, dc := j/g.BoxCols(), j%g.BoxCols()
+			s.Board.SetForce(g.MakePos(boxRow+dr, boxCol+dc), val)
 		}
 	}
 }
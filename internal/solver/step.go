@@ -0,0 +1,150 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// Technique identifies a human-style solving technique.
+// Techniques are ordered by increasing difficulty.
+type Technique int
+
+const (
+	HiddenSingle Technique = iota
+	NakedSingle
+	PointingCandidates
+	ClaimingCandidates
+	NakedPair
+	HiddenPair
+	NakedTriple
+	HiddenTriple
+	NakedQuad
+	HiddenQuad
+	XWing
+	Swordfish
+	Jellyfish
+	XYWing
+	XYZWing
+	WWing
+	SimpleColoring
+	UniqueRectangle
+
+	techniqueCount
+)
+
+var techniqueNames = [techniqueCount]string{
+	HiddenSingle:       "Hidden Single",
+	NakedSingle:        "Naked Single",
+	PointingCandidates: "Pointing Candidates",
+	ClaimingCandidates: "Claiming Candidates",
+	NakedPair:          "Naked Pair",
+	HiddenPair:         "Hidden Pair",
+	NakedTriple:        "Naked Triple",
+	HiddenTriple:       "Hidden Triple",
+	NakedQuad:          "Naked Quad",
+	HiddenQuad:         "Hidden Quad",
+	XWing:              "X-Wing",
+	Swordfish:          "Swordfish",
+	Jellyfish:          "Jellyfish",
+	XYWing:             "XY-Wing",
+	XYZWing:            "XYZ-Wing",
+	WWing:              "W-Wing",
+	SimpleColoring:     "Simple Coloring",
+	UniqueRectangle:    "Unique Rectangle",
+}
+
+// String returns the conventional name of the technique.
+func (t Technique) String() string {
+	if t < 0 || t >= techniqueCount {
+		return fmt.Sprintf("Technique(%d)", int(t))
+	}
+	return techniqueNames[t]
+}
+
+// Techniques returns every technique in order of increasing difficulty.
+func Techniques() []Technique {
+	all := make([]Technique, techniqueCount)
+	for t := range all {
+		all[t] = Technique(t)
+	}
+	return all
+}
+
+// Candidate identifies a digit in a cell.
+type Candidate struct {
+	Pos int
+	Val int
+}
+
+// Step is a single deduction made by the logical solver.
+type Step struct {
+	Technique    Technique
+	Units        []board.Unit // Units the pattern lives in
+	Cells        []int        // Cells forming the pattern
+	Digits       []int        // Digits the pattern is built on
+	Placements   []Candidate  // Values placed by the step
+	Eliminations []Candidate  // Candidates removed by the step
+
+	Geometry *board.Geometry // Geometry of the board the step was found on, nil for 9x9
+}
+
+// String returns a one-line description of the step,
+// e.g. "Naked Pair in row 1: r1c2,r1c5 {3,7} => r1c4<>3, r1c4<>7".
+func (s *Step) String() string {
+	var sb strings.Builder
+	sb.WriteString(s.Technique.String())
+
+	if len(s.Units) > 0 {
+		names := make([]string, len(s.Units))
+		for i, u := range s.Units {
+			names[i] = u.String()
+		}
+		sb.WriteString(" in ")
+		sb.WriteString(strings.Join(names, ", "))
+	}
+
+	g := s.Geometry
+	if g == nil {
+		g = board.Standard
+	}
+
+	sb.WriteString(": ")
+	names := make([]string, len(s.Cells))
+	for i, pos := range s.Cells {
+		names[i] = g.CellName(pos)
+	}
+	sb.WriteString(strings.Join(names, ","))
+
+	digits := make([]string, len(s.Digits))
+	for i, d := range s.Digits {
+		digits[i] = fmt.Sprint(d)
+	}
+	sb.WriteString(" {" + strings.Join(digits, ",") + "}")
+
+	var results []string
+	for _, c := range s.Placements {
+		results = append(results, fmt.Sprintf("%s=%d", g.CellName(c.Pos), c.Val))
+	}
+	for _, c := range s.Eliminations {
+		results = append(results, fmt.Sprintf("%s<>%d", g.CellName(c.Pos), c.Val))
+	}
+	sb.WriteString(" => ")
+	sb.WriteString(strings.Join(results, ", "))
+
+	return sb.String()
+}
+
+// ParseTechnique converts a technique name into a Technique.
+// Matching ignores case, spaces and hyphens, so "x-wing", "XWing" and "X Wing" are all accepted.
+func ParseTechnique(s string) (Technique, error) {
+	normalize := strings.NewReplacer(" ", "", "-", "", "_", "")
+	want := strings.ToLower(normalize.Replace(s))
+	for _, t := range Techniques() {
+		if strings.ToLower(normalize.Replace(t.String())) == want {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownTechnique, s)
+}
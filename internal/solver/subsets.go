@@ -0,0 +1,116 @@
+package solver
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// nakedSubsetFinder returns a finder for n cells in a unit whose candidates
+// are limited to the same n digits, which removes those digits from the rest of the unit.
+func nakedSubsetFinder(technique Technique, n int) finder {
+	return func(ls *LogicalSolver) *Step {
+		for _, u := range ls.geo().Units() {
+			var cells []int
+			for _, pos := range u.Cells {
+				if count := bits.OnesCount(ls.Candidates(pos)); count >= 2 && count <= n {
+					cells = append(cells, pos)
+				}
+			}
+
+			var step *Step
+			combinations(len(cells), n, func(idx []int) bool {
+				subset := make([]int, n)
+				var union uint
+				for i, j := range idx {
+					subset[i] = cells[j]
+					union |= ls.Candidates(cells[j])
+				}
+				if bits.OnesCount(union) != n {
+					return true
+				}
+
+				var elims []Candidate
+				for _, val := range maskDigits(union) {
+					elims = append(elims, ls.eliminationsFrom(u.Cells, val, subset...)...)
+				}
+				if len(elims) == 0 {
+					return true
+				}
+
+				step = &Step{
+					Technique:    technique,
+					Units:        []board.Unit{u},
+					Cells:        subset,
+					Digits:       maskDigits(union),
+					Eliminations: elims,
+				}
+				return false
+			})
+			if step != nil {
+				return step
+			}
+		}
+		return nil
+	}
+}
+
+// hiddenSubsetFinder returns a finder for n digits confined to the same n cells of a unit,
+// which removes every other candidate from those cells.
+func hiddenSubsetFinder(technique Technique, n int) finder {
+	return func(ls *LogicalSolver) *Step {
+		for _, u := range ls.geo().Units() {
+			var digits []int
+			places := make([][]int, ls.Board.Size()+1)
+			for val := 1; val <= ls.Board.Size(); val++ {
+				places[val] = ls.cellsWith(u, val)
+				if count := len(places[val]); count >= 2 && count <= n {
+					digits = append(digits, val)
+				}
+			}
+
+			var step *Step
+			combinations(len(digits), n, func(idx []int) bool {
+				var subset []int
+				var digitSet uint
+				for _, j := range idx {
+					val := digits[j]
+					digitSet |= digitMask(val)
+					for _, pos := range places[val] {
+						if !slices.Contains(subset, pos) {
+							subset = append(subset, pos)
+						}
+					}
+				}
+				if len(subset) != n {
+					return true
+				}
+
+				var elims []Candidate
+				for _, pos := range subset {
+					for _, val := range maskDigits(ls.Candidates(pos) &^ digitSet) {
+						elims = append(elims, Candidate{Pos: pos, Val: val})
+					}
+				}
+				if len(elims) == 0 {
+					return true
+				}
+
+				slices.Sort(subset)
+				step = &Step{
+					Technique:    technique,
+					Units:        []board.Unit{u},
+					Cells:        subset,
+					Digits:       maskDigits(digitSet),
+					Eliminations: elims,
+				}
+				return false
+			})
+			if step != nil {
+				return step
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package solver
+
+import (
+	"math/bits"
+)
+
+// findUniqueRectangle looks for four empty cells spanning two rows, two columns and two boxes
+// that could all be reduced to the same pair {x,y}. Such a "deadly pattern" would let x and y swap
+// freely, giving a second solution, so a puzzle with a unique solution must avoid it:
+//   - Type 1: three corners are exactly {x,y}, so the fourth cannot be x or y;
+//   - Type 2: two corners are exactly {x,y} and the other two are {x,y,z}, so one of them is z
+//     and z is removed from cells seeing both.
+//
+// Jigsaw regions need not pair the corners up, and extra units, rules and constraints such as cages
+// can tell x and y apart, so boards with any of them are skipped.
+func findUniqueRectangle(ls *LogicalSolver) *Step {
+	g := ls.geo()
+	if g.Jigsaw() || g.ExtraUnits() != 0 || g.Rules() != 0 || len(ls.Board.Constraints()) > 0 {
+		return nil
+	}
+	for r1 := 0; r1 < g.Size(); r1++ {
+		for r2 := r1 + 1; r2 < g.Size(); r2++ {
+			for c1 := 0; c1 < g.Size(); c1++ {
+				for c2 := c1 + 1; c2 < g.Size(); c2++ {
+					corners := []int{
+						g.MakePos(r1, c1), g.MakePos(r1, c2),
+						g.MakePos(r2, c1), g.MakePos(r2, c2),
+					}
+					// Corners must occupy exactly two boxes
+					if (g.BoxOf(corners[0]) == g.BoxOf(corners[2])) == (g.BoxOf(corners[0]) == g.BoxOf(corners[1])) {
+						continue
+					}
+					if step := ls.checkRectangle(corners); step != nil {
+						return step
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRectangle tests the four corners of a rectangle for a Type 1 or Type 2 unique rectangle.
+func (ls *LogicalSolver) checkRectangle(corners []int) *Step {
+	common := ls.geo().AllDigits()
+	for _, pos := range corners {
+		common &= ls.Candidates(pos)
+	}
+	if bits.OnesCount(common) < 2 {
+		return nil
+	}
+
+	var step *Step
+	digits := maskDigits(common)
+	combinations(len(digits), 2, func(idx []int) bool {
+		pair := digitMask(digits[idx[0]]) | digitMask(digits[idx[1]])
+
+		var floor, roof []int
+		for _, pos := range corners {
+			if ls.Candidates(pos) == pair {
+				floor = append(floor, pos)
+			} else {
+				roof = append(roof, pos)
+			}
+		}
+
+		var elims []Candidate
+		used := []int{digits[idx[0]], digits[idx[1]]}
+		switch len(floor) {
+		case 3:
+			for _, val := range used {
+				elims = append(elims, ls.eliminationsFrom(roof, val)...)
+			}
+		case 2:
+			extra := ls.Candidates(roof[0]) &^ pair
+			if ls.Candidates(roof[1]) != ls.Candidates(roof[0]) || bits.OnesCount(extra) != 1 {
+				return true
+			}
+			val := bits.TrailingZeros(extra) + 1
+			elims = ls.eliminationsFrom(ls.commonPeers(roof[0], roof[1]), val, corners...)
+			used = append(used, val)
+		}
+		if len(elims) == 0 {
+			return true
+		}
+
+		step = &Step{
+			Technique:    UniqueRectangle,
+			Cells:        corners,
+			Digits:       used,
+			Eliminations: elims,
+		}
+		return false
+	})
+
+	return step
+}
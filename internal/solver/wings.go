@@ -0,0 +1,128 @@
+package solver
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/rybkr/sudoku/internal/board"
+)
+
+// findXYWing finds a bivalue pivot {x,y} seeing bivalue pincers {x,z} and {y,z}.
+// Whichever value the pivot takes, one pincer is z, so z is removed from cells seeing both pincers.
+func findXYWing(ls *LogicalSolver) *Step {
+	for pivot := 0; pivot < ls.Board.CellCount(); pivot++ {
+		pm := ls.Candidates(pivot)
+		if bits.OnesCount(pm) != 2 {
+			continue
+		}
+
+		for _, a := range ls.geo().Peers(pivot) {
+			am := ls.Candidates(a)
+			if bits.OnesCount(am) != 2 || bits.OnesCount(am&pm) != 1 {
+				continue
+			}
+			z := am &^ pm
+			bm := (pm &^ am) | z
+
+			for _, b := range ls.geo().Peers(pivot) {
+				if b <= a || ls.Candidates(b) != bm {
+					continue
+				}
+				val := bits.TrailingZeros(z) + 1
+				elims := ls.eliminationsFrom(ls.commonPeers(a, b), val, pivot)
+				if len(elims) == 0 {
+					continue
+				}
+				return &Step{
+					Technique:    XYWing,
+					Cells:        []int{pivot, a, b},
+					Digits:       maskDigits(pm | z),
+					Eliminations: elims,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findXYZWing finds a trivalue pivot {x,y,z} seeing bivalue pincers {x,z} and {y,z}.
+// One of the three cells must be z, so z is removed from cells seeing all of them.
+func findXYZWing(ls *LogicalSolver) *Step {
+	for pivot := 0; pivot < ls.Board.CellCount(); pivot++ {
+		pm := ls.Candidates(pivot)
+		if bits.OnesCount(pm) != 3 {
+			continue
+		}
+
+		for _, a := range ls.geo().Peers(pivot) {
+			am := ls.Candidates(a)
+			if bits.OnesCount(am) != 2 || am&^pm != 0 {
+				continue
+			}
+
+			for _, b := range ls.geo().Peers(pivot) {
+				bm := ls.Candidates(b)
+				if b <= a || bits.OnesCount(bm) != 2 || bm&^pm != 0 || am|bm != pm {
+					continue
+				}
+				val := bits.TrailingZeros(am&bm) + 1
+				elims := ls.eliminationsFrom(ls.commonPeers(pivot, a, b), val)
+				if len(elims) == 0 {
+					continue
+				}
+				return &Step{
+					Technique:    XYZWing,
+					Cells:        []int{pivot, a, b},
+					Digits:       maskDigits(pm),
+					Eliminations: elims,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findWWing finds two identical bivalue cells {x,y} that do not see each other,
+// joined by a strong link on x. One of the two cells must be y,
+// so y is removed from cells seeing both.
+func findWWing(ls *LogicalSolver) *Step {
+	for a := 0; a < ls.Board.CellCount(); a++ {
+		mask := ls.Candidates(a)
+		if bits.OnesCount(mask) != 2 {
+			continue
+		}
+
+		for b := a + 1; b < ls.Board.CellCount(); b++ {
+			if ls.Candidates(b) != mask || ls.geo().Sees(a, b) {
+				continue
+			}
+
+			for _, x := range maskDigits(mask) {
+				y := maskDigits(mask &^ digitMask(x))[0]
+				elims := ls.eliminationsFrom(ls.commonPeers(a, b), y)
+				if len(elims) == 0 {
+					continue
+				}
+
+				for _, u := range ls.geo().Units() {
+					link := ls.cellsWith(u, x)
+					if len(link) != 2 || slices.Contains(link, a) || slices.Contains(link, b) {
+						continue
+					}
+					p, q := link[0], link[1]
+					if !(ls.geo().Sees(p, a) && ls.geo().Sees(q, b)) && !(ls.geo().Sees(p, b) && ls.geo().Sees(q, a)) {
+						continue
+					}
+					return &Step{
+						Technique:    WWing,
+						Units:        []board.Unit{u},
+						Cells:        []int{a, b, p, q},
+						Digits:       []int{x, y},
+						Eliminations: elims,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}